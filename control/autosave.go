@@ -0,0 +1,200 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rootVIII/visipy/utils"
+)
+
+// autosaveInterval is how often the whole project is snapshotted; commands
+// in between are kept in the journal so nothing is lost before the next one.
+const autosaveInterval = 30 * time.Second
+
+// Each instance autosaves to files named after its PID, so instances
+// running at the same time never touch each other's session. A session
+// whose process has exited is claimed by renaming it to the recover files
+// of the instance that offers to restore it.
+const (
+	autosavePrefix = "autosave-"
+	recoverPrefix  = "recover-"
+	snapshotExt    = ".json"
+	journalExt     = ".journal"
+	typesExt       = ".types"
+)
+
+// sessionTypes is the content of the types file saved next to a snapshot:
+// the custom widget types and template overrides of the project, which
+// live next to the project file rather than the snapshot.
+type sessionTypes struct {
+	Widgets   []WidgetSchema    `json:"widgets"`
+	Templates map[string]string `json:"templates"`
+}
+
+// prepareRecovery claims the most recent session left behind by a crashed
+// instance, moving it out of the way of the new autosave, and reports
+// whether there is one to offer the user. Older crashed sessions are
+// offered by later instances.
+func (app *AppParser) prepareRecovery() bool {
+	if len(app.DataDir) < 1 {
+		return false
+	}
+	var crashed struct {
+		prefix   string
+		pid      int
+		modified time.Time
+	}
+	for _, prefix := range []string{autosavePrefix, recoverPrefix} {
+		paths, _ := filepath.Glob(filepath.Join(app.DataDir, prefix+"*"))
+		for _, path := range paths {
+			name := strings.TrimPrefix(filepath.Base(path), prefix)
+			pid, err := strconv.Atoi(strings.TrimSuffix(name, filepath.Ext(name)))
+			if err != nil || pid == os.Getpid() || utils.ProcessAlive(pid) {
+				continue
+			}
+			info, err := os.Stat(path)
+			if err == nil && info.ModTime().After(crashed.modified) {
+				crashed.prefix, crashed.pid, crashed.modified = prefix, pid, info.ModTime()
+			}
+		}
+	}
+	if crashed.pid == 0 {
+		return false
+	}
+
+	found := false
+	for _, ext := range []string{snapshotExt, journalExt, typesExt} {
+		from := app.sessionPath(crashed.prefix, crashed.pid, ext)
+		if os.Rename(from, app.sessionPath(recoverPrefix, os.Getpid(), ext)) == nil {
+			found = true
+		}
+	}
+	return found
+}
+
+// restoreSession loads the crashed session's last snapshot and replays the
+// journal of commands made after it. When the restore fails, the session
+// is kept so that it can be offered again.
+func (app *AppParser) restoreSession() error {
+	snapshot := app.sessionPath(recoverPrefix, os.Getpid(), snapshotExt)
+	if _, err := os.Stat(snapshot); err == nil {
+		var types sessionTypes
+		raw, err := ioutil.ReadFile(app.sessionPath(recoverPrefix, os.Getpid(), typesExt))
+		if err == nil {
+			err = json.Unmarshal(raw, &types)
+		}
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("%s: %v", snapshot, err)
+		}
+		app.initUserApp()
+		if err := app.mergeProject(snapshot, types.Widgets, types.Templates); err != nil {
+			return err
+		}
+	}
+
+	journal, err := os.Open(app.sessionPath(recoverPrefix, os.Getpid(), journalExt))
+	if err == nil {
+		scanner := bufio.NewScanner(journal)
+		lineNumber := 0
+		for scanner.Scan() {
+			lineNumber++
+			command := strings.Split(scanner.Text(), "|$|")
+			if err := app.ApplyCommand(command); err != nil {
+				journal.Close()
+				return fmt.Errorf("journal line %d: %v", lineNumber, err)
+			}
+		}
+		journal.Close()
+	}
+
+	app.discardRecovery()
+	return app.autosave()
+}
+
+// discardRecovery removes a crashed session the user chose not to restore.
+func (app *AppParser) discardRecovery() {
+	os.Remove(app.sessionPath(recoverPrefix, os.Getpid(), snapshotExt))
+	os.Remove(app.sessionPath(recoverPrefix, os.Getpid(), journalExt))
+	os.Remove(app.sessionPath(recoverPrefix, os.Getpid(), typesExt))
+}
+
+// journalCommand appends a successfully applied command to the journal and
+// takes a full snapshot once the autosave interval has passed. UNDO and
+// REDO always take a snapshot: the undo history isn't saved, so they
+// could not be replayed from the journal.
+func (app *AppParser) journalCommand(command []string) {
+	if len(app.DataDir) < 1 {
+		return
+	}
+	if command[0] == "UNDO" || command[0] == "REDO" ||
+		(undoable[command[0]] && time.Since(app.lastAutosave) > autosaveInterval) {
+		app.autosave()
+		return
+	}
+	if !undoable[command[0]] {
+		return
+	}
+	journal, err := os.OpenFile(
+		app.sessionPath(autosavePrefix, os.Getpid(), journalExt), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return
+	}
+	defer journal.Close()
+	fmt.Fprintln(journal, strings.Join(command, "|$|"))
+}
+
+// autosave snapshots the project, with its custom widget types and
+// template overrides, and starts a new, empty journal.
+func (app *AppParser) autosave() error {
+	if len(app.DataDir) < 1 {
+		return nil
+	}
+	types := sessionTypes{Templates: app.projectTemplates}
+	for _, schema := range app.projectWidgets {
+		types.Widgets = append(types.Widgets, schema)
+	}
+	rawTypes, _ := json.Marshal(types)
+	rawProject, _ := json.Marshal(app.MapBuild)
+	for _, file := range []struct {
+		ext string
+		raw []byte
+	}{{typesExt, rawTypes}, {snapshotExt, rawProject}} {
+		path := app.sessionPath(autosavePrefix, os.Getpid(), file.ext)
+		tmp := path + ".tmp"
+		if err := app.Utils.WriteFile(tmp, file.raw); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			return err
+		}
+	}
+	app.lastAutosave = time.Now()
+	os.Remove(app.sessionPath(autosavePrefix, os.Getpid(), journalExt))
+	return nil
+}
+
+// clearAutosave removes the autosave once the designer exits cleanly.
+func (app *AppParser) clearAutosave() {
+	if len(app.DataDir) < 1 {
+		return
+	}
+	os.Remove(app.sessionPath(autosavePrefix, os.Getpid(), snapshotExt))
+	os.Remove(app.sessionPath(autosavePrefix, os.Getpid(), journalExt))
+	os.Remove(app.sessionPath(autosavePrefix, os.Getpid(), typesExt))
+}
+
+// sessionPath returns the path of the snapshot, journal or types file of the autosaved
+// or recovered session of the instance with the given PID.
+func (app *AppParser) sessionPath(prefix string, pid int, ext string) string {
+	return filepath.Join(app.DataDir, fmt.Sprintf("%s%d%s", prefix, pid, ext))
+}
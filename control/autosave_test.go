@@ -0,0 +1,127 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// exitedPID returns the PID of a process that has already exited.
+func exitedPID(t *testing.T) int {
+	command := exec.Command("true")
+	if err := command.Run(); err != nil {
+		t.Skip("no process to wait for:", err)
+	}
+	return command.Process.Pid
+}
+
+// restoreCrashed hands the session of crashed over to a new instance, as
+// if its own had died, and restores it there.
+func restoreCrashed(t *testing.T, crashed *AppParser) *AppParser {
+	t.Helper()
+	pid := exitedPID(t)
+	for _, ext := range []string{snapshotExt, journalExt, typesExt} {
+		from := crashed.sessionPath(autosavePrefix, os.Getpid(), ext)
+		os.Rename(from, crashed.sessionPath(autosavePrefix, pid, ext))
+	}
+
+	app := &AppParser{DataDir: crashed.DataDir}
+	app.initUserApp()
+	if !app.prepareRecovery() {
+		t.Fatal("crashed session not found")
+	}
+	if err := app.restoreSession(); err != nil {
+		t.Fatal(err)
+	}
+	return app
+}
+
+func TestRestoreSessionAfterUndo(t *testing.T) {
+	crashed := &AppParser{DataDir: t.TempDir()}
+	crashed.initUserApp()
+	for _, command := range [][]string{
+		{"ADD", "Button", "name|@|one|:|row|@|0|:|column|@|0"},
+		{"ADD", "Button", "name|@|two|:|row|@|1|:|column|@|0"},
+		{"UNDO"},
+		{"UNDO"},
+		{"ADD", "Button", "name|@|three|:|row|@|2|:|column|@|0"},
+	} {
+		if err := crashed.ApplyCommand(command); err != nil {
+			t.Fatalf("%v: %v", command, err)
+		}
+		crashed.journalCommand(command)
+	}
+
+	app := restoreCrashed(t, crashed)
+	for name, want := range map[string]bool{"one": false, "two": false, "three": true} {
+		if _, got := app.MapBuild[name]; got != want {
+			t.Errorf("widget %s restored = %v, want %v", name, got, want)
+		}
+	}
+	if _, err := os.Stat(app.sessionPath(recoverPrefix, os.Getpid(), snapshotExt)); err == nil {
+		t.Error("recovered session left behind")
+	}
+}
+
+func TestPrepareRecoveryKeepsRunningInstances(t *testing.T) {
+	app := &AppParser{DataDir: t.TempDir()}
+	running := app.sessionPath(autosavePrefix, os.Getppid(), snapshotExt)
+	if err := os.WriteFile(running, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if app.prepareRecovery() {
+		t.Error("claimed the session of a running instance")
+	}
+	if _, err := os.Stat(running); err != nil {
+		t.Error("running instance's autosave moved:", err)
+	}
+	app.clearAutosave()
+	if _, err := os.Stat(running); err != nil {
+		t.Error("running instance's autosave removed:", err)
+	}
+}
+
+func TestRestoreSessionWithCustomWidgets(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"project.json":        gaugeProject,
+		"widgets/Gauge.json":  `{"name": "Gauge", "options": [{"name": "text"}]}`,
+		"templates/quit.tmpl": "\ndef quit_():\n\tprint('bye')\n\texit()\n\n",
+	})
+	crashed := &AppParser{DataDir: t.TempDir()}
+	crashed.initUserApp()
+	load := []string{"LOADUSERPROJ", filepath.Join(dir, "project.json")}
+	if err := crashed.ApplyCommand(load); err != nil {
+		t.Fatal(err)
+	}
+	if err := crashed.autosave(); err != nil {
+		t.Fatal(err)
+	}
+	add := []string{"ADD", "Gauge", "name|@|h|:|row|@|1|:|column|@|0|:|text|@|Flow"}
+	if err := crashed.ApplyCommand(add); err != nil {
+		t.Fatal(err)
+	}
+	crashed.journalCommand(add)
+
+	// The snapshot must not depend on the project directory.
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	app := restoreCrashed(t, crashed)
+	if err := app.generate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"self.g = Gauge(", "self.h = Gauge(", "text='Flow'", "print('bye')"} {
+		if !strings.Contains(app.Build.String(), want) {
+			t.Errorf("restored code lacks %q:\n%s", want, app.Build.String())
+		}
+	}
+	if _, err := os.Stat(app.sessionPath(recoverPrefix, os.Getpid(), typesExt)); err == nil {
+		t.Error("recovered types left behind")
+	}
+}
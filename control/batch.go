@@ -0,0 +1,54 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// RunBatch applies a script of designer commands without starting the GUI.
+// Each line holds one command in the same format the designer pipes to the
+// controller, e.g. "TITLE|$|MyApp" or "ADD|$|Button|$|name|@|ok|:|row|@|0".
+// Blank lines and lines starting with # are ignored, and EXIT ends the script.
+func (app *AppParser) RunBatch(script io.Reader) error {
+	app.setIndent()
+	app.initUserApp()
+	if err := app.generate(); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(script)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) < 1 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		app.STDOUT = strings.Split(line, "|$|")
+		if app.STDOUT[0] == "EXIT" {
+			break
+		}
+		if app.STDOUT[0] == "BUILD" {
+			return fmt.Errorf("line %d: BUILD is not available in batch mode", lineNumber)
+		}
+		if err := app.ApplyCommand(app.STDOUT); err != nil {
+			return fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+		if err := app.generate(); err != nil {
+			return fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// WriteBuild writes the current build to out.
+func (app *AppParser) WriteBuild(out io.Writer) error {
+	_, err := out.Write(app.Build.Bytes())
+	return err
+}
@@ -0,0 +1,35 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// checkTheme refuses ttk themes the target interpreter doesn't have, when
+// its themes could be probed.
+func (app *AppParser) checkTheme(theme string) error {
+	themes := app.Capabilities.Themes
+	if len(themes) < 1 || contains(themes, theme) {
+		return nil
+	}
+	return &OptionError{"theme", fmt.Sprintf("allowable values: %s", strings.Join(themes, ", "))}
+}
+
+// checkImage refuses an image the designer picks that doesn't exist, or is
+// a PNG the target interpreter's Tk can't load (before Tk 8.6). Loaded
+// projects skip it, since their images may live on another machine.
+func (app *AppParser) checkImage(name, path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return &OptionError{name, fmt.Sprintf("invalid image file %s", path)}
+	}
+	caps := app.Capabilities
+	if len(caps.Tk) < 1 || caps.PNG || !strings.EqualFold(filepath.Ext(path), ".png") {
+		return nil
+	}
+	return &OptionError{name, fmt.Sprintf("Tk %s can't load PNG images, use a GIF", caps.Tk)}
+}
@@ -0,0 +1,85 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// compileCheck byte-compiles a build with the user's interpreter and prints
+// the line and message of a syntax error.
+const compileCheck = `import py_compile, sys
+try:
+    py_compile.compile(sys.argv[1], cfile=sys.argv[2], doraise=True)
+except py_compile.PyCompileError as err:
+    print('%s|%s' % (getattr(err.exc_value, 'lineno', 0),
+                     getattr(err.exc_value, 'msg', err.msg)))
+    sys.exit(1)
+`
+
+// BuildError is a syntax error in the generated code, with the part of the
+// project whose code it is in when that could be found.
+type BuildError struct {
+	Line    int
+	Source  string
+	Message string
+}
+
+func (err *BuildError) Error() string {
+	switch {
+	case len(err.Source) > 0:
+		return fmt.Sprintf("%s, line %d: %s", err.Source, err.Line, err.Message)
+	case err.Line > 0:
+		return fmt.Sprintf("line %d: %s", err.Line, err.Message)
+	}
+	return err.Message
+}
+
+// checkBuild compiles the build written to path. A build that compiles is
+// kept as the last known good one, which BUILD runs while the current
+// build is broken.
+func (app *AppParser) checkBuild(path string) error {
+	if len(app.Executable) < 1 {
+		return nil
+	}
+	cfile := fmt.Sprintf("%s.pyc", app.Project)
+	defer os.Remove(cfile)
+	output, err := exec.Command(app.Executable, "-c", compileCheck, path, cfile).Output()
+	if err != nil {
+		exitErr, failed := err.(*exec.ExitError)
+		if !failed {
+			return nil // no interpreter to check with
+		}
+		fields := strings.SplitN(strings.TrimSpace(string(output)), "|", 2)
+		line, lineErr := strconv.Atoi(fields[0])
+		if len(fields) < 2 || lineErr != nil {
+			// The check itself failed, e.g. the interpreter crashed, so
+			// nothing is known about the build.
+			return fmt.Errorf("compile check: %v%s", err, lastLine(exitErr.Stderr))
+		}
+		app.buildError = &BuildError{Line: line, Message: fields[1]}
+		app.buildError.Source = app.SourceMap.Lookup(line)
+		return app.buildError
+	}
+	app.buildError, app.goodSources = nil, app.SourceMap
+	return app.Utils.WriteFile(app.goodBuild(), app.Build.Bytes())
+}
+
+// lastLine returns the last line of a process's error output, which ends a
+// Python traceback with the exception, prefixed by ": ".
+func lastLine(stderr []byte) string {
+	lines := strings.Split(strings.TrimSpace(string(stderr)), "\n")
+	if last := strings.TrimSpace(lines[len(lines)-1]); len(last) > 0 {
+		return ": " + last
+	}
+	return ""
+}
+
+func (app *AppParser) goodBuild() string {
+	return fmt.Sprintf("%s.good.py", app.Project)
+}
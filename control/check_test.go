@@ -0,0 +1,99 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckBuild(t *testing.T) {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		python = ""
+	}
+	for _, test := range []struct {
+		name   string
+		script string // the interpreter, a shell script; python3 when empty
+		code   string
+		err    string
+		broken bool
+	}{
+		{"compiles", "", "x = 1\n", "", false},
+		{"syntax error", "", "x = (\n", "line 1: ", true},
+		{"no line", "echo '0|source code cannot contain null bytes'; exit 1", "",
+			"source code cannot contain null bytes", true},
+		{"silent crash", "exit 3", "", "compile check: exit status 3", false},
+		{"traceback", "echo 'Traceback (most recent call last):' >&2; echo 'MemoryError' >&2; exit 1", "",
+			"compile check: exit status 1: MemoryError", false},
+		{"no interpreter", "missing", "", "", false},
+	} {
+		dir := t.TempDir()
+		app := &AppParser{Project: filepath.Join(dir, "project"), Executable: python}
+		switch test.script {
+		case "":
+			if len(python) < 1 {
+				continue
+			}
+		case "missing":
+			app.Executable = filepath.Join(dir, "missing")
+		default:
+			app.Executable = filepath.Join(dir, "python")
+			if err := os.WriteFile(app.Executable, []byte("#!/bin/sh\n"+test.script+"\n"), 0755); err != nil {
+				t.Fatal(err)
+			}
+		}
+		path := filepath.Join(dir, "project.py")
+		if err := os.WriteFile(path, []byte(test.code), 0644); err != nil {
+			t.Fatal(err)
+		}
+		err := app.checkBuild(path)
+		if got := errorText(err); len(test.err) < 1 && len(got) > 0 || len(test.err) > 0 && !strings.HasPrefix(got, test.err) {
+			t.Errorf("%s: error %q, want %q", test.name, got, test.err)
+		}
+		if _, broken := err.(*BuildError); broken != test.broken || (app.buildError != nil) != test.broken {
+			t.Errorf("%s: build marked broken = %v, want %v", test.name, broken, test.broken)
+		}
+	}
+}
+
+func TestRunTemplateChecksBeforeUpdate(t *testing.T) {
+	// The fake interpreter records what it was given to check and whether
+	// the designer could already have taken the update, then reports a
+	// syntax error.
+	dir := t.TempDir()
+	project := filepath.Join(dir, "project")
+	app := newTestApp(t)
+	app.Project, app.Executable = project, filepath.Join(dir, "python")
+	script := "#!/bin/sh\necho \"$3\" > " + project + ".checked\n" +
+		"ls " + project + ".json.update " + project + ".py.update >> " + project + ".checked 2>&1\n" +
+		"echo '3|invalid syntax'; exit 1\n"
+	if err := os.WriteFile(app.Executable, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	app.RunTemplate(false)
+
+	checked, err := os.ReadFile(project + ".checked")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(checked)), "\n")
+	if lines[0] != project+".check.py" || strings.Contains(string(checked), "update\n") {
+		t.Errorf("checked:\n%s\nwant a private copy, before either update was written", checked)
+	}
+	if _, err := os.Stat(project + ".check.py"); err == nil {
+		t.Error("the checked copy was left behind")
+	}
+	for _, path := range []string{project + ".py.update", project + ".json.update"} {
+		if _, err := os.Stat(path); err != nil {
+			t.Error("update not written:", err)
+		}
+	}
+	if message, err := os.ReadFile(project + ".error"); err != nil || !strings.Contains(string(message), "line 3: invalid syntax") {
+		t.Errorf("error file %q, %v; want the syntax error", message, err)
+	}
+}
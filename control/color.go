@@ -0,0 +1,88 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseColor accepts any color Tk understands and returns its canonical
+// form: a named color lower-cased without spaces ("steel blue" becomes
+// "steelblue"), a hex color as #rrggbb, and a platform system color
+// (e.g. SystemButtonFace) unchanged. Six hex digits without the leading #
+// are accepted too, as the designer has always allowed them.
+func ParseColor(color string) (string, error) {
+	color = strings.TrimSpace(color)
+	name := strings.ToLower(strings.Join(strings.Fields(color), ""))
+	if _, exists := x11Colors[name]; exists {
+		return name, nil
+	}
+	// Tk looks system colors up without dropping spaces.
+	if strings.HasPrefix(name, "system") && len(name) > len("system") && isLetters(name) &&
+		!strings.ContainsAny(color, " \t") {
+		return color, nil
+	}
+	if len(name) == 6 && isHex(name) {
+		name = "#" + name
+	}
+
+	digits := strings.TrimPrefix(name, "#")
+	if len(digits) == len(name) || !isHex(digits) {
+		return "", fmt.Errorf("%q is not a color", color)
+	}
+	switch len(digits) {
+	case 3, 6, 9, 12:
+	default:
+		return "", fmt.Errorf("%q: hex colors need 3, 6, 9 or 12 digits", color)
+	}
+
+	// Tk treats each component as the most significant bits of a 16 bit
+	// value, so #3a7 is #30a070 and #123456789abc is #12569a.
+	width := len(digits) / 3
+	canonical := "#"
+	for component := 0; component < 3; component++ {
+		value := digits[component*width : (component+1)*width]
+		canonical += (value + "0")[:2]
+	}
+	return canonical, nil
+}
+
+// ColorRGB returns the RGB value of a canonical color. System colors are
+// approximated by a light gray.
+func ColorRGB(color string) ([3]uint8, bool) {
+	if rgb, exists := x11Colors[color]; exists {
+		return rgb, true
+	}
+	if strings.HasPrefix(strings.ToLower(color), "system") {
+		return [3]uint8{240, 240, 240}, true
+	}
+	if len(color) != 7 || color[0] != '#' {
+		return [3]uint8{}, false
+	}
+	value, err := strconv.ParseUint(color[1:], 16, 32)
+	if err != nil {
+		return [3]uint8{}, false
+	}
+	return [3]uint8{uint8(value >> 16), uint8(value >> 8), uint8(value)}, true
+}
+
+func isHex(value string) bool {
+	for _, char := range value {
+		if !strings.ContainsRune("0123456789abcdef", char) {
+			return false
+		}
+	}
+	return len(value) > 0
+}
+
+func isLetters(value string) bool {
+	for _, char := range value {
+		if char < 'a' || char > 'z' {
+			return false
+		}
+	}
+	return true
+}
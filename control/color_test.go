@@ -0,0 +1,56 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import "testing"
+
+func TestParseColor(t *testing.T) {
+	for _, test := range []struct {
+		color, want, err string
+	}{
+		{"red", "red", ""},
+		{" Steel Blue ", "steelblue", ""},
+		{"LightGoldenrodYellow", "lightgoldenrodyellow", ""},
+		{"light goldenrod yellow", "lightgoldenrodyellow", ""},
+		{"SystemButtonFace", "SystemButtonFace", ""},
+		{"#3a7", "#30a070", ""},
+		{"#FFF", "#f0f0f0", ""},
+		{"#A0B1C2", "#a0b1c2", ""},
+		{"a0b1c2", "#a0b1c2", ""},
+		{"#123456789", "#124578", ""},
+		{"#123456789abc", "#12569a", ""},
+		{"", "", `"" is not a color`},
+		{"reddish", "", `"reddish" is not a color`},
+		{"steel  bleu", "", `"steel  bleu" is not a color`},
+		{"system", "", `"system" is not a color`},
+		{"System Button Face", "", `"System Button Face" is not a color`},
+		{"#12g", "", `"#12g" is not a color`},
+		{"#", "", `"#" is not a color`},
+		{"#1234", "", `"#1234": hex colors need 3, 6, 9 or 12 digits`},
+		{"abc", "", `"abc" is not a color`},
+	} {
+		got, err := ParseColor(test.color)
+		if got != test.want || errorText(err) != test.err {
+			t.Errorf("ParseColor(%q) = %q, %q; want %q, %q", test.color, got, errorText(err), test.want, test.err)
+		}
+	}
+}
+
+func TestColorRGB(t *testing.T) {
+	for _, test := range []struct {
+		color string
+		want  [3]uint8
+		ok    bool
+	}{
+		{"red", [3]uint8{255, 0, 0}, true},
+		{"#30a070", [3]uint8{0x30, 0xa0, 0x70}, true},
+		{"SystemButtonFace", [3]uint8{240, 240, 240}, true},
+		{"#3a7", [3]uint8{}, false},
+		{"reddish", [3]uint8{}, false},
+	} {
+		if got, ok := ColorRGB(test.color); got != test.want || ok != test.ok {
+			t.Errorf("ColorRGB(%q) = %v, %v; want %v, %v", test.color, got, ok, test.want, test.ok)
+		}
+	}
+}
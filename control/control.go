@@ -8,9 +8,15 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
 	"strings"
-	"text/template"
+	"syscall"
+	"time"
 
 	"github.com/rootVIII/visipy/utils"
 )
@@ -22,94 +28,291 @@ type Controller interface {
 
 // AppController controls the entire GUI application.
 type AppController struct {
-	STDOUT   []string
-	Build    bytes.Buffer
-	MapBuild map[string]map[string]interface{}
-	I1b      []byte
-	I1       string
-	I2       string
+	STDOUT    []string
+	Build     bytes.Buffer
+	SourceMap SourceMap
+	MapBuild  map[string]map[string]interface{}
+	I1b       []byte
+	I1        string
+	I2        string
+	// The custom widget types and template overrides of the project.
+	projectWidgets   map[string]WidgetSchema
+	projectTemplates map[string]string
 }
 
 // AppParser inherits AppController for parsing output.
 type AppParser struct {
 	AppController
-	Executable string
-	VisiPath   string
-	Project    string
-	HaveIcon   bool
-	Utils      utils.Bootstrap
+	Executable   string
+	VisiPath     string
+	Project      string
+	DataDir      string
+	Capabilities utils.Capabilities
+	HaveIcon     bool
+	Utils        utils.Bootstrap
+	KeepPreviews bool
+	LivePreview  bool
+	lastAutosave time.Time
+	previews     previews
+	liveTimer    *time.Timer
+	liveBuild    []byte
+	buildError   *BuildError
+	goodSources  SourceMap
+	undo         []snapshot
+	redo         []snapshot
 }
 
 // RunVisipy runs the application and listens to incoming commands.
 func (app *AppParser) RunVisipy() {
-	app.I1b = []byte{0x20, 0x20, 0x20, 0x20}
-	app.I1 = string(app.I1b)
-	app.I2 = app.I1 + app.I1
+	app.setIndent()
 
 	// Set AppController's initial current builds and buffers.
 	app.initUserApp()
 	app.RunTemplate(true)
 
-	command := exec.Command(app.Executable, app.VisiPath)
+	args := []string{app.VisiPath}
+	if app.prepareRecovery() {
+		args = append(args, "--recover")
+	}
+	command := exec.Command(app.Executable, args...)
 	stdout, _ := command.StdoutPipe()
 	command.Start()
 	scanner := bufio.NewScanner(stdout)
 
-	for scanner.Scan() {
+	// On SIGINT or SIGTERM, closing the designer ends the loop below so
+	// that the previews are stopped and the caller can clean up.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		if _, received := <-signals; received {
+			command.Process.Kill()
+		}
+	}()
 
+	for scanner.Scan() {
 		app.STDOUT = strings.Split(scanner.Text(), "|$|")
 
 		switch app.STDOUT[0] {
-		case "ADD":
-			app.SetWidget(app.STDOUT[1], strings.Split(app.STDOUT[2], "|:|"))
-		case "EXIT":
-			break
-		case "LOADUSERPROJ":
-			app.loadExistingProject(app.STDOUT[1])
-		case "RESET":
-			app.initUserApp()
-		case "BUILD":
-			app.RunJob()
-		case "REMOVE":
-			delete(app.MapBuild, app.STDOUT[1])
-			if app.STDOUT[1] == "ICON" {
-				app.HaveIcon = false
-			}
-		case "WRITE":
-			jsonBytes, _ := json.Marshal(app.MapBuild)
-			if app.STDOUT[1][len(app.STDOUT[1])-3:] != ".py" {
-				app.STDOUT[1] += ".py"
-			}
-			app.Utils.WriteFile(app.STDOUT[1]+".project", jsonBytes)
-			app.Utils.WriteFile(app.STDOUT[1], app.Build.Bytes())
-		case "APPCOLOR":
-			app.MapBuild["APPCOLOR"]["appcolor"] = app.STDOUT[1]
-		case "DIMENSIONS":
-			app.MapBuild["DIMENSIONS"]["dimensions"] = app.STDOUT[1]
-		case "ICON":
-			app.MapBuild["ICON"] = make(map[string]interface{})
-			app.MapBuild["ICON"]["iconpath"] = app.STDOUT[1]
-			app.HaveIcon = true
-		case "MENU":
-			menuItems := strings.Split(app.STDOUT[1], ",")
-			_, hasMenu := app.MapBuild[menuItems[0]]
-			if !hasMenu {
-				app.MapBuild[menuItems[0]] = make(map[string]interface{})
+		case "RESTORE":
+			err := app.restoreSession()
+			if err != nil {
+				err = fmt.Errorf("RESTORE: %v", err)
 			}
-			for index, value := range menuItems[1:] {
-				app.MapBuild[menuItems[0]][fmt.Sprintf("submenu%d", index)] = value
+			app.reportError(err)
+		case "DISCARD":
+			app.discardRecovery()
+		case "EXIT":
+			app.clearAutosave()
+			app.closePreviews()
+		default:
+			err := app.ApplyCommand(app.STDOUT)
+			app.reportError(err)
+			if err == nil {
+				app.journalCommand(app.STDOUT)
 			}
-		case "THEME":
-			app.MapBuild["THEME"]["theme"] = app.STDOUT[1]
-		case "TITLE":
-			app.MapBuild["TITLE"]["title"] = app.STDOUT[1]
-		case "MENUCOLOR":
-			colors := strings.Split(app.STDOUT[1], "|:|")
-			app.MapBuild["MENUCOLOR"]["foreground"] = colors[0]
-			app.MapBuild["MENUCOLOR"]["background"] = colors[1]
 		}
 		app.RunTemplate(false)
 	}
+	app.closePreviews()
+	command.Wait()
+}
+
+// ApplyCommand applies a single designer command to the current project,
+// recording the previous state for UNDO when the command changes it.
+func (app *AppParser) ApplyCommand(command []string) error {
+	if !undoable[command[0]] {
+		return app.applyCommand(command)
+	}
+	before := app.takeSnapshot()
+	err := app.applyCommand(command)
+	if err == nil {
+		app.pushHistory(before)
+	}
+	return err
+}
+
+func (app *AppParser) applyCommand(command []string) error {
+	switch command[0] {
+	case "EXIT", "RESET", "BUILD", "UNDO", "REDO":
+	default:
+		if len(command) < 2 {
+			return fmt.Errorf("%s: missing argument", command[0])
+		}
+	}
+
+	switch command[0] {
+	case "ADD":
+		if len(command) < 3 {
+			return fmt.Errorf("ADD: missing widget attributes")
+		}
+		return app.SetWidget(command[1], strings.Split(command[2], "|:|"))
+	case "EXIT":
+		break
+	case "LOADUSERPROJ":
+		return app.loadExistingProject(command[1])
+	case "RESET":
+		app.initUserApp()
+	case "BUILD":
+		return app.RunJob()
+	case "PREVIEW":
+		switch command[1] {
+		case "replace":
+			app.KeepPreviews = false
+		case "keep":
+			app.KeepPreviews = true
+		case "live":
+			app.LivePreview = true
+		case "manual":
+			app.LivePreview = false
+			app.stopLivePreview()
+		default:
+			return fmt.Errorf("PREVIEW: expected replace, keep, live or manual")
+		}
+	case "RENAME":
+		names := strings.Split(command[1], "|:|")
+		if len(names) != 2 {
+			return fmt.Errorf("RENAME: expected old|:|new")
+		}
+		return app.RenameWidget(names[0], names[1])
+	case "DUPLICATE":
+		args := strings.Split(command[1], "|:|")
+		if len(args) != 1 && len(args) != 3 {
+			return fmt.Errorf("DUPLICATE: expected name[|:|row offset|:|column offset]")
+		}
+		offsets, err := intArgs(append(args[1:], "0", "0")[:2])
+		if err != nil {
+			return fmt.Errorf("DUPLICATE: %v", err)
+		}
+		_, err = app.DuplicateWidget(args[0], offsets[0], offsets[1])
+		return err
+	case "MOVE":
+		args := strings.Split(command[1], "|:|")
+		if len(args) != 3 && len(args) != 4 {
+			return fmt.Errorf("MOVE: expected name|:|row|:|column[|:|parent]")
+		}
+		cell, err := intArgs(args[1:3])
+		if err != nil {
+			return fmt.Errorf("MOVE: %v", err)
+		}
+		parent := parentOf(app.MapBuild[args[0]])
+		if len(args) == 4 {
+			parent = args[3]
+		}
+		return app.MoveWidget(args[0], cell[0], cell[1], parent)
+	case "INSERTROW", "DELETEROW", "INSERTCOLUMN", "DELETECOLUMN":
+		args := strings.Split(command[1], "|:|")
+		if len(args) > 2 {
+			return fmt.Errorf("%s: expected index[|:|parent]", command[0])
+		}
+		index, err := intArgs(args[:1])
+		if err != nil {
+			return fmt.Errorf("%s: %v", command[0], err)
+		}
+		return map[string]func(int, string) error{
+			"INSERTROW":    app.InsertRow,
+			"DELETEROW":    app.DeleteRow,
+			"INSERTCOLUMN": app.InsertColumn,
+			"DELETECOLUMN": app.DeleteColumn,
+		}[command[0]](index[0], append(args, "")[1])
+	case "UNDO":
+		return app.Undo()
+	case "REDO":
+		return app.Redo()
+	case "REMOVE":
+		if children := app.children(command[1]); len(command[1]) > 0 && len(children) > 0 {
+			return fmt.Errorf("REMOVE: %s still contains %s", command[1], strings.Join(children, ", "))
+		}
+		delete(app.MapBuild, command[1])
+		if command[1] == "ICON" {
+			app.HaveIcon = false
+		}
+	case "WRITE":
+		jsonBytes, _ := json.Marshal(app.MapBuild)
+		if !strings.HasSuffix(command[1], ".py") {
+			command[1] += ".py"
+		}
+		err := app.Utils.WriteFile(command[1]+".project", jsonBytes)
+		if err != nil {
+			return err
+		}
+		return app.Utils.WriteFile(command[1], app.Build.Bytes())
+	case "APPCOLOR":
+		color, err := ParseColor(command[1])
+		if err != nil {
+			return err
+		}
+		app.MapBuild["APPCOLOR"]["appcolor"] = color
+	case "DIMENSIONS":
+		app.MapBuild["DIMENSIONS"]["dimensions"] = command[1]
+	case "ICON":
+		if err := app.checkImage("ICON", command[1]); err != nil {
+			return err
+		}
+		app.MapBuild["ICON"] = make(map[string]interface{})
+		app.MapBuild["ICON"]["iconpath"] = command[1]
+		app.HaveIcon = true
+	case "MENU":
+		menuItems := strings.Split(command[1], ",")
+		if len(menuItems) < 2 {
+			return fmt.Errorf("MENU: expected title,submenu[,submenu...]")
+		}
+		_, hasMenu := app.MapBuild[menuItems[0]]
+		if !hasMenu {
+			app.MapBuild[menuItems[0]] = make(map[string]interface{})
+		}
+		for index, value := range menuItems[1:] {
+			app.MapBuild[menuItems[0]][fmt.Sprintf("submenu%d", index)] = value
+		}
+	case "THEME":
+		if err := app.checkTheme(command[1]); err != nil {
+			return err
+		}
+		app.MapBuild["THEME"]["theme"] = command[1]
+	case "TITLE":
+		return app.SetMetadata([]string{"title|@|" + command[1]})
+	case "META":
+		return app.SetMetadata(strings.Split(command[1], "|:|"))
+	case "STYLE":
+		return app.SetStyle(strings.Split(command[1], "|:|"))
+	case "TARGET":
+		return app.SetTarget(strings.Split(command[1], "|:|"))
+	case "MENUCOLOR":
+		colors := strings.Split(command[1], "|:|")
+		if len(colors) != 2 {
+			return fmt.Errorf("MENUCOLOR: expected foreground|:|background")
+		}
+		foreground, err := ParseColor(colors[0])
+		if err != nil {
+			return fmt.Errorf("MENUCOLOR foreground: %v", err)
+		}
+		background, err := ParseColor(colors[1])
+		if err != nil {
+			return fmt.Errorf("MENUCOLOR background: %v", err)
+		}
+		app.MapBuild["MENUCOLOR"]["foreground"] = foreground
+		app.MapBuild["MENUCOLOR"]["background"] = background
+	default:
+		return fmt.Errorf("unknown command %q", command[0])
+	}
+	return nil
+}
+
+// reportError leaves a refused command's error for the designer to show,
+// or clears the previous one.
+func (app *AppParser) reportError(err error) {
+	errorPath := fmt.Sprintf("%s.error", app.Project)
+	if err == nil {
+		os.Remove(errorPath)
+		return
+	}
+	app.Utils.WriteFile(errorPath, []byte(err.Error()))
+}
+
+func (app *AppParser) setIndent() {
+	app.I1b = bytes.Repeat([]byte{0x20}, app.codeStyle().Indent)
+	app.I1 = string(app.I1b)
+	app.I2 = app.I1 + app.I1
 }
 
 func (app *AppParser) initUserApp() {
@@ -126,92 +329,218 @@ func (app *AppParser) initUserApp() {
 	app.MapBuild["APPCOLOR"]["appcolor"] = "#000000"
 	app.MapBuild["THEME"]["theme"] = "default"
 	app.HaveIcon = false
+	app.projectWidgets, app.projectTemplates = nil, nil
 }
 
 // RunTemplate templates map values into code snippets.
 func (app *AppParser) RunTemplate(initialBuild bool) {
+	if err := app.generate(); err != nil {
+		app.reportError(err)
+	}
+	rawProject, _ := json.Marshal(app.MapBuild)
+
+	var lint bytes.Buffer
+	for _, warning := range app.Lint() {
+		fmt.Fprintln(&lint, warning)
+	}
+	app.Utils.WriteFile(fmt.Sprintf("%s.lint", app.Project), lint.Bytes())
+	app.writeWidgetTypes()
+	app.writeSourceMap()
+
+	// The designer takes the update, and then the error, as soon as
+	// project.json.update appears, so the build is checked from a copy of
+	// its own and project.json.update is written last.
+	checkPath := fmt.Sprintf("%s.check.py", app.Project)
+	app.Utils.WriteFile(checkPath, app.Build.Bytes())
+	err := app.checkBuild(checkPath)
+	os.Remove(checkPath)
+	if err != nil {
+		if _, broken := err.(*BuildError); broken {
+			err = fmt.Errorf("%v (Run uses the last build that compiled)", err)
+		}
+		app.reportError(err)
+	}
+
+	buildPath := fmt.Sprintf("%s.py", app.Project)
+	if initialBuild {
+		app.Utils.WriteFile(buildPath, app.Build.Bytes())
+	} else {
+		app.Utils.WriteFile(buildPath+".update", app.Build.Bytes())
+		app.Utils.WriteFile(fmt.Sprintf("%s.json.update", app.Project), rawProject)
+	}
+	if app.LivePreview {
+		app.reloadPreview()
+	}
+}
+
+// generate rebuilds the Python source for the current project into Build.
+// A template that fails leaves its part of the code out; the first such
+// error is returned once the rest of the code is built.
+func (app *AppParser) generate() error {
+	style := app.codeStyle()
+	app.setIndent()
 	app.Build.Reset()
-	app.Build.Write(app.getSysImport())
-	app.Build.Write(app.getStyleImport())
-	app.Build.Write(app.getImports())
-
-	out, _ := template.New("classinit").Parse(app.getClassInit())
-	out.Execute(&app.Build, app.MapBuild["TITLE"])
-	out, _ = template.New("apptitle").Parse(app.getAppTitle())
-	out.Execute(&app.Build, app.MapBuild["TITLE"])
-	out, _ = template.New("appcolor").Parse(app.getAppColor())
-	out.Execute(&app.Build, app.MapBuild["APPCOLOR"])
-	out, _ = template.New("dimensions").Parse(app.getAppDimensions())
-	out.Execute(&app.Build, app.MapBuild["DIMENSIONS"])
-	out, _ = template.New("menucolor").Parse(app.getMenuInit())
-	out.Execute(&app.Build, app.MapBuild["MENUCOLOR"])
+	var marks []sourceMark
+	mark := func(source string) {
+		marks = append(marks, sourceMark{source, app.Build.Len()})
+	}
+	var failed error
+	render := func(out io.Writer, name string, data interface{}) {
+		if err := app.render(out, name, data); err != nil && failed == nil {
+			failed = fmt.Errorf("template %s: %v", name, err)
+		}
+	}
+
+	meta := app.metadata()
+	mark("setting META")
+	render(&app.Build, "classinit", meta)
+	mark("setting TITLE")
+	render(&app.Build, "apptitle", meta)
+	mark("setting APPCOLOR")
+	render(&app.Build, "appcolor", app.MapBuild["APPCOLOR"])
+	mark("setting DIMENSIONS")
+	render(&app.Build, "dimensions", app.MapBuild["DIMENSIONS"])
+	mark("setting MENUCOLOR")
+	render(&app.Build, "menucolor", app.MapBuild["MENUCOLOR"])
 
 	if app.HaveIcon {
-		out, _ = template.New("icon").Parse(app.getAppIcon())
-		out.Execute(&app.Build, app.MapBuild["ICON"])
+		mark("setting ICON")
+		render(&app.Build, "icon", app.MapBuild["ICON"])
 	}
-	for key, value := range app.MapBuild {
+	for _, key := range app.sortedKeys() {
+		value := app.MapBuild[key]
 		_, hasSub := value["submenu0"]
 		if hasSub {
-			out, _ = template.New(key).Parse(app.getMenuItem(key, int8(len(value))))
-			out.Execute(&app.Build, app.MapBuild[key])
+			mark("menu " + key)
+			render(&app.Build, "menu", menuData(key, value))
 		}
 	}
 
-	var methods []string
-	for key, value := range app.MapBuild {
-		_, isWidget := value["row"]
-		if !isWidget {
-			continue
-		}
+	var methods []handler
+	for _, key := range app.widgetOrder() {
+		value := app.MapBuild[key]
 		for k, v := range value {
-			ival, isint := v.(int)
-			if isint && ival == -1 {
-				delete(value, k)
-			}
-			sval, isstring := v.(string)
-			if isstring && len(sval) < 1 {
+			if isUnset(v) {
 				delete(value, k)
 			}
 		}
 
 		var tmpbuf bytes.Buffer
+		mark("widget " + key)
+		widgetType, _ := value["widget"].(string)
+		schema, known := app.lookupWidget(widgetType)
+		if !known {
+			if failed == nil {
+				failed = fmt.Errorf("widget %s: unknown widget type %q", key, widgetType)
+			}
+			continue
+		}
 		_, isImage := value["image"]
-		if isImage {
-			tmp, _ := template.New(key).Parse(app.getImgWidget())
-			tmp.Execute(&tmpbuf, value)
+		if isImage && len(schema.Template) < 1 {
+			render(&tmpbuf, "image", pythonAttrs(value, schema))
 			app.ReviseWidget(tmpbuf)
 			continue
 		}
 
-		methodName, hasCommand := value["command"]
-		if hasCommand && len(methodName.(string)) > 0 {
-			methods = append(methods, methodName.(string))
+		// The options are in schema order so the methods are generated in
+		// the same order every time.
+		signatures := schema.HandlerSignatures()
+		for _, option := range schema.Options {
+			signature, isHandler := signatures[option.Name]
+			methodName, hasHandler := value[option.Name].(string)
+			if isHandler && hasHandler && len(methodName) > 0 && !hasMethod(methods, methodName) {
+				methods = append(methods, handler{methodName, signature})
+			}
+		}
+		if schema.builtin && len(schema.Template) < 1 {
+			render(&tmpbuf, "widget", pythonAttrs(value, schema))
+			app.ReviseWidget(tmpbuf)
+			continue
+		}
+		widgetTemplate := schema.Template
+		if len(widgetTemplate) < 1 {
+			widgetTemplate = getCustomWidget(schema)
+		}
+		tmp, err := app.parse(key, widgetTemplate)
+		if err == nil {
+			err = tmp.Execute(&tmpbuf, pythonAttrs(value, schema))
+		}
+		if err != nil {
+			if failed == nil {
+				failed = fmt.Errorf("%s widget %s: %v", widgetType, key, err)
+			}
+			continue
 		}
-		tmp, _ := template.New(key).Parse(app.getWidget())
-		tmp.Execute(&tmpbuf, value)
 		app.ReviseWidget(tmpbuf)
 	}
 
 	if len(methods) > 0 {
-		app.Build.Write(app.getMethods(methods))
+		mark("code methods")
+		render(&app.Build, "methods", map[string]interface{}{"methods": methods})
 	}
-	app.Build.Write(app.getQuit())
-	out, _ = template.New("theme").Parse(app.getGui())
-	out.Execute(&app.Build, app.MapBuild)
-	app.Build.Write(app.getMain())
-	rawProject, _ := json.Marshal(app.MapBuild)
+	project := app.templateProject()
+	mark("code quit")
+	render(&app.Build, "quit", project)
+	mark("setting THEME")
+	render(&app.Build, "gui", project)
+	mark("code main")
+	render(&app.Build, "main", project)
 
-	if initialBuild {
-		app.Utils.WriteFile(fmt.Sprintf("%s.py", app.Project), app.Build.Bytes())
-	} else {
-		app.Utils.WriteFile(fmt.Sprintf("%s.json.update", app.Project), rawProject)
-		app.Utils.WriteFile(fmt.Sprintf("%s.py.update", app.Project), app.Build.Bytes())
+	// The imports depend on the names the rest of the code uses.
+	imports, body := importLines(app.substituteTarget(app.Build.String()), app.widgetImports(), style)
+	bodyMarks := marks
+	markLines(app.Build.Bytes(), bodyMarks, 0)
+	app.Build.Reset()
+	marks = nil
+	mark("setting META")
+	render(&app.Build, "header", meta)
+	mark("code imports")
+	render(&app.Build, "imports", map[string]interface{}{"imports": imports})
+	markLines(app.Build.Bytes(), marks, 0)
+	shift := bytes.Count(app.Build.Bytes(), []byte("\n"))
+	for _, bodyMark := range bodyMarks {
+		marks = append(marks, sourceMark{bodyMark.source, bodyMark.start + shift})
 	}
+
+	app.Build.WriteString(body)
+	styled, lineMap := restyle(app.Build.String(), style)
+	app.Build.Reset()
+	app.Build.WriteString(styled)
+	app.SourceMap = newSourceMap(marks, lineMap)
+	return failed
+}
+
+// LoadProject replaces the current project with a saved .project file.
+func (app *AppParser) LoadProject(projectPath string) error {
+	app.setIndent()
+	app.initUserApp()
+	if err := app.loadExistingProject(projectPath); err != nil {
+		return err
+	}
+	return app.generate()
+}
+
+// loadExistingProject merges the project file at projectPath, and the
+// custom widget types and templates next to it, into the open project.
+func (app *AppParser) loadExistingProject(projectPath string) error {
+	schemas, templates, err := readProjectTypes(filepath.Dir(projectPath))
+	if err != nil {
+		return err
+	}
+	return app.mergeProject(projectPath, schemas, templates)
 }
 
-func (app *AppParser) loadExistingProject(projectPath string) {
-	for key, value := range app.Utils.ReadJSON(projectPath) {
+// mergeProject adds the widgets of the project file at projectPath, along
+// with the custom widget types and template overrides they use, to the
+// open project. Nothing is changed when a widget turns out to be invalid.
+func (app *AppParser) mergeProject(projectPath string, schemas []WidgetSchema, templates map[string]string) error {
+	project := app.Utils.ReadJSON(projectPath)
+	if project == nil {
+		return fmt.Errorf("unable to read project %s", projectPath)
+	}
+	before := app.takeSnapshot()
+	app.mergeProjectTypes(schemas, templates)
+	for key, value := range project {
 		if key == "ICON" {
 			app.HaveIcon = true
 		}
@@ -219,14 +548,169 @@ func (app *AppParser) loadExistingProject(projectPath string) {
 		if !exists {
 			app.MapBuild[key] = make(map[string]interface{})
 		}
-		for innerKey, innerValue := range value.(map[string]interface{}) {
+		inner, isMap := value.(map[string]interface{})
+		if !isMap {
+			continue
+		}
+		for innerKey, innerValue := range inner {
 			app.MapBuild[key][innerKey] = innerValue
 		}
 	}
+	if err := app.validateWidgets(); err != nil {
+		app.restoreSnapshot(before)
+		return fmt.Errorf("%s: %v", projectPath, err)
+	}
+	return nil
+}
+
+// readProjectTypes reads the custom widget types and template overrides in
+// the widgets and templates directories next to the project in dir.
+func readProjectTypes(dir string) ([]WidgetSchema, map[string]string, error) {
+	schemas, err := readWidgetDir(filepath.Join(dir, WidgetDirName))
+	if err != nil {
+		return nil, nil, err
+	}
+	templates, err := readTemplateDir(filepath.Join(dir, TemplateDirName))
+	if err != nil {
+		return nil, nil, err
+	}
+	return schemas, templates, nil
+}
+
+// mergeProjectTypes adds custom widget types and template overrides to the
+// project's. Like the widgets of a loaded project, they replace those of the
+// same name and keep the rest. The maps are copied first, since snapshots
+// share them.
+func (app *AppParser) mergeProjectTypes(schemas []WidgetSchema, templates map[string]string) {
+	widgets := make(map[string]WidgetSchema, len(app.projectWidgets)+len(schemas))
+	for name, schema := range app.projectWidgets {
+		widgets[name] = schema
+	}
+	for _, schema := range schemas {
+		widgets[schema.Name] = schema
+	}
+	merged := make(map[string]string, len(app.projectTemplates)+len(templates))
+	for name, text := range app.projectTemplates {
+		merged[name] = text
+	}
+	for name, text := range templates {
+		merged[name] = text
+	}
+	app.projectWidgets, app.projectTemplates = widgets, merged
+}
+
+// validateWidgets checks every widget in the project as ADD would, so a
+// hand-edited or outdated project file can't slip past the schema. The
+// error names each widget that fails.
+func (app *AppParser) validateWidgets() error {
+	var problems []string
+	for _, name := range app.sortedKeys() {
+		value := app.MapBuild[name]
+		widgetType, isWidget := value["widget"].(string)
+		if _, hasRow := value["row"]; !isWidget && !hasRow {
+			continue
+		}
+		schema, known := app.lookupWidget(widgetType)
+		err := fmt.Errorf("unknown widget type %q", widgetType)
+		if known {
+			err = schema.Validate(value)
+		}
+		if err == nil {
+			err = app.checkParent(name, parentOf(value))
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid widgets: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// RunJob runs the user's current Python app, or the last build that
+// compiled while the current one has a syntax error. Its output goes to the
+// console file the designer shows.
+func (app *AppParser) RunJob() error {
+	build, sources := fmt.Sprintf("%s.py", app.Project), app.SourceMap
+	if app.buildError != nil {
+		build, sources = app.goodBuild(), app.goodSources
+	}
+	return app.startPreview(build, sources, !app.KeepPreviews)
+}
+
+// sortedKeys returns the names in the project in order, so that the
+// generated code is the same for the same project.
+func (cont AppController) sortedKeys() []string {
+	keys := make([]string, 0, len(cont.MapBuild))
+	for key := range cont.MapBuild {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// widgetOrder returns the names of the widgets by row, then column, then
+// name, the order they are generated in, moving each container ahead of
+// the widgets placed in it.
+func (cont AppController) widgetOrder() []string {
+	var names []string
+	for _, key := range cont.sortedKeys() {
+		if _, isWidget := cont.MapBuild[key]["row"]; isWidget {
+			names = append(names, key)
+		}
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		rowI, _ := intAttr(cont.MapBuild[names[i]], "row")
+		rowJ, _ := intAttr(cont.MapBuild[names[j]], "row")
+		if rowI != rowJ {
+			return rowI < rowJ
+		}
+		columnI, _ := intAttr(cont.MapBuild[names[i]], "column")
+		columnJ, _ := intAttr(cont.MapBuild[names[j]], "column")
+		return columnI < columnJ
+	})
+
+	ordered := make([]string, 0, len(names))
+	added := make(map[string]bool, len(names))
+	var add func(name string)
+	add = func(name string) {
+		if added[name] {
+			return
+		}
+		added[name] = true
+		parent := parentOf(cont.MapBuild[name])
+		if _, isWidget := cont.MapBuild[parent]["row"]; isWidget {
+			add(parent)
+		}
+		ordered = append(ordered, name)
+	}
+	for _, name := range names {
+		add(name)
+	}
+	return ordered
 }
 
-// RunJob runs the user's current Python app.
-func (app *AppParser) RunJob() {
-	cmd := exec.Command(app.Executable, fmt.Sprintf("%s.py", app.Project))
-	_ = cmd.Start()
+// widgetImports returns the imports custom widget types in the project
+// need, sorted and without duplicates.
+func (app *AppParser) widgetImports() []string {
+	var imports []string
+	for _, value := range app.MapBuild {
+		widgetType, _ := value["widget"].(string)
+		schema, known := app.lookupWidget(widgetType)
+		if known && len(schema.Import) > 0 && !contains(imports, schema.Import) {
+			imports = append(imports, schema.Import)
+		}
+	}
+	sort.Strings(imports)
+	return imports
+}
+
+func contains(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
 }
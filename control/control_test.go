@@ -0,0 +1,127 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeProject saves a project file the way the designer does.
+func writeProject(t *testing.T, project map[string]map[string]interface{}) string {
+	t.Helper()
+	raw, err := json.Marshal(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "project.json")
+	if err := os.WriteFile(path, raw, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadProjectRoundTrip(t *testing.T) {
+	saved := newTestApp(t,
+		"ADD|$|Frame|$|name|@|box|:|row|@|0|:|column|@|0",
+		"ADD|$|Button|$|name|@|ok|:|row|@|0|:|column|@|0|:|parent|@|box|:|text|@|OK|:|command|@|ok_clicked",
+		"ADD|$|Scale|$|name|@|size|:|row|@|1|:|column|@|0|:|from|@|0|:|to|@|10",
+	)
+	// Saved before generating, unset options are still in the file as -1.
+	path := writeProject(t, saved.MapBuild)
+	if err := saved.generate(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := &AppParser{}
+	if err := loaded.LoadProject(path); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := loaded.Build.String(), saved.Build.String(); got != want {
+		t.Errorf("loaded project generates\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestLoadProjectValidatesWidgets(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		widget map[string]interface{}
+		err    string
+	}{
+		{"type", map[string]interface{}{"widget": "Gizmo", "row": "0", "column": "0"},
+			`bad: unknown widget type "Gizmo"`},
+		{"no type", map[string]interface{}{"row": "0", "column": "0"},
+			`bad: unknown widget type ""`},
+		{"option", map[string]interface{}{"widget": "Label", "row": "0", "column": "0", "colour": "red"},
+			"bad: colour: not supported by Label"},
+		{"value", map[string]interface{}{"widget": "Label", "row": "0", "column": "0", "foreground": "reddish"},
+			"bad: foreground:"},
+		{"cell", map[string]interface{}{"widget": "Label", "row": "100000000", "column": "0"},
+			"bad: row: must be from 0 to 9999"},
+		{"missing cell", map[string]interface{}{"widget": "Label", "column": "0"},
+			"bad: row: attribute is required"},
+		{"parent", map[string]interface{}{"widget": "Label", "row": "0", "column": "0", "parent": "ok"},
+			"bad: parent: ok is a Label, not a container"},
+		{"cycle", map[string]interface{}{"widget": "Frame", "row": "0", "column": "0", "parent": "bad"},
+			"bad: bad can't be placed inside itself"},
+	} {
+		app := newTestApp(t, "ADD|$|Label|$|name|@|ok|:|row|@|0|:|column|@|0", "TITLE|$|Kept")
+		project := copyBuild(newTestApp(t, "ADD|$|Label|$|name|@|good|:|row|@|1|:|column|@|0").MapBuild)
+		test.widget["name"] = "bad"
+		project["bad"] = test.widget
+		err := app.ApplyCommand([]string{"LOADUSERPROJ", writeProject(t, project)})
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: error %v, want %q", test.name, err, test.err)
+		}
+		if _, loaded := app.MapBuild["good"]; loaded {
+			t.Errorf("%s: loaded part of an invalid project", test.name)
+		}
+		if app.MapBuild["TITLE"]["title"] != "Kept" {
+			t.Errorf("%s: the open project changed", test.name)
+		}
+	}
+
+	if _, err := OpenProject(writeProject(t, map[string]map[string]interface{}{
+		"a": {"widget": "Label", "name": "a", "row": "-3", "column": "0"},
+		"b": {"widget": "Label", "name": "b", "row": "0", "column": "0", "parent": "a"},
+	})); err == nil || !strings.Contains(err.Error(), "invalid widgets: a: row: must be from 0 to 9999; b: parent: a is a Label") {
+		t.Errorf("OpenProject: error %v, want both widgets named", err)
+	}
+}
+
+func TestLoadProjectWithMissingImage(t *testing.T) {
+	// Images are checked when the designer picks them; a project made on
+	// another machine still opens.
+	missing := filepath.Join(t.TempDir(), "logo.gif")
+	project, err := OpenProject(writeProject(t, map[string]map[string]interface{}{
+		"logo": {"widget": "Image", "name": "logo", "row": "0", "column": "0", "image": missing},
+		"ICON": {"iconpath": missing},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	project.Lint()
+	var code, image bytes.Buffer
+	if err := project.Generate(&code); err != nil {
+		t.Fatal(err)
+	}
+	if err := project.RenderPNG(&image); err != nil {
+		t.Fatal(err)
+	}
+
+	app := newTestApp(t)
+	for _, command := range [][]string{
+		{"ADD", "Image", "name|@|logo|:|row|@|0|:|column|@|0|:|image|@|" + missing},
+		{"ICON", missing},
+	} {
+		err := app.ApplyCommand(command)
+		if want := "invalid image file " + missing; err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: error %v, want %q", command[0], err, want)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+var pythonKeywords = map[string]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true,
+	"assert": true, "async": true, "await": true, "break": true,
+	"class": true, "continue": true, "def": true, "del": true, "elif": true,
+	"else": true, "except": true, "finally": true, "for": true, "from": true,
+	"global": true, "if": true, "import": true, "in": true, "is": true,
+	"lambda": true, "nonlocal": true, "not": true, "or": true, "pass": true,
+	"raise": true, "return": true, "try": true, "while": true, "with": true,
+	"yield": true,
+}
+
+// projectSettings are the names of the project entries that aren't widgets
+// or menus, some of which only exist once set.
+var projectSettings = []string{
+	"TITLE", "APPCOLOR", "DIMENSIONS", "MENUCOLOR", "THEME", "ICON", "STYLE",
+	"META", "TARGET",
+}
+
+// validName reports whether name can be used for a new widget.
+func (app *AppParser) validName(name string) error {
+	if !identifier.MatchString(name) || pythonKeywords[name] {
+		return fmt.Errorf("%q is not a valid Python name", name)
+	}
+	if contains(projectSettings, name) {
+		return fmt.Errorf("%q is a project setting", name)
+	}
+	if _, exists := app.MapBuild[name]; exists {
+		return fmt.Errorf("%q already exists", name)
+	}
+	return nil
+}
+
+// widget returns the attributes of an existing widget.
+func (app *AppParser) widget(name string) (map[string]interface{}, error) {
+	value, exists := app.MapBuild[name]
+	if !exists {
+		return nil, fmt.Errorf("no widget named %q", name)
+	}
+	if _, isWidget := value["row"]; !isWidget {
+		return nil, fmt.Errorf("%q is not a widget", name)
+	}
+	return value, nil
+}
+
+// RenameWidget renames a widget. The handlers and variables it names after
+// itself (e.g. ok_clicked for ok) are renamed with it, wherever they are
+// used, and so is its name as the parent of other widgets.
+func (app *AppParser) RenameWidget(oldName, newName string) error {
+	value, err := app.widget(oldName)
+	if err != nil {
+		return err
+	}
+	if err := app.validName(newName); err != nil {
+		return err
+	}
+
+	renamed := make(map[string]string)
+	for _, option := range app.referenceOptions(value) {
+		reference, isString := value[option].(string)
+		if isString && len(reference) > 0 {
+			if newReference := renameReference(reference, oldName, newName); newReference != reference {
+				renamed[reference] = newReference
+			}
+		}
+	}
+	delete(app.MapBuild, oldName)
+	value["name"] = newName
+	app.MapBuild[newName] = value
+
+	for _, other := range app.widgets() {
+		if other["parent"] == oldName {
+			other["parent"] = newName
+		}
+		for _, option := range app.referenceOptions(other) {
+			if reference, isString := other[option].(string); isString && len(renamed[reference]) > 0 {
+				other[option] = renamed[reference]
+			}
+		}
+	}
+	return nil
+}
+
+// DuplicateWidget copies a widget under a new unique name, shifted by the
+// given number of rows and columns in the same parent, and returns the new
+// name. Only the widget is copied, not the widgets inside a container.
+func (app *AppParser) DuplicateWidget(name string, rowOffset, columnOffset int) (string, error) {
+	value, err := app.widget(name)
+	if err != nil {
+		return "", err
+	}
+
+	newName := app.uniqueName(name)
+	dup := make(map[string]interface{}, len(value))
+	for attr, val := range value {
+		dup[attr] = val
+	}
+	dup["name"] = newName
+	for _, option := range app.referenceOptions(dup) {
+		if reference, isString := dup[option].(string); isString {
+			dup[option] = renameReference(reference, name, newName)
+		}
+	}
+	for attr, offset := range map[string]int{"row": rowOffset, "column": columnOffset} {
+		cell, isSet := intAttr(dup, attr)
+		if !isSet {
+			continue
+		}
+		_, span, _ := gridCell(dup, attr, attr+"span")
+		if cell+offset < 0 {
+			return "", fmt.Errorf("%s %d is outside the grid", attr, cell+offset)
+		}
+		if err := checkCell(attr, cell+offset, span); err != nil {
+			return "", err
+		}
+		dup[attr] = strconv.Itoa(cell + offset)
+	}
+
+	app.MapBuild[newName] = dup
+	return newName, nil
+}
+
+// MoveWidget places a widget in a different grid cell of parent, the name
+// of a container widget or empty for the window.
+func (app *AppParser) MoveWidget(name string, row, column int, parent string) error {
+	value, err := app.widget(name)
+	if err != nil {
+		return err
+	}
+	if row < 0 || column < 0 {
+		return fmt.Errorf("row and column must not be negative")
+	}
+	_, rowSpan, _ := gridCell(value, "row", "rowspan")
+	_, columnSpan, _ := gridCell(value, "column", "columnspan")
+	if err := checkCell("row", row, rowSpan); err != nil {
+		return err
+	}
+	if err := checkCell("column", column, columnSpan); err != nil {
+		return err
+	}
+	if err := app.checkParent(name, parent); err != nil {
+		return err
+	}
+	value["row"] = strconv.Itoa(row)
+	value["column"] = strconv.Itoa(column)
+	delete(value, "parent")
+	if len(parent) > 0 {
+		value["parent"] = parent
+	}
+	return nil
+}
+
+// checkParent reports whether the widget called name can be placed in
+// parent: a container widget that isn't name or inside it.
+func (app *AppParser) checkParent(name, parent string) error {
+	if len(parent) < 1 {
+		return nil
+	}
+	seen := make(map[string]bool)
+	for container := parent; len(container) > 0; container = parentOf(app.MapBuild[container]) {
+		if container == name || seen[container] {
+			return fmt.Errorf("%s can't be placed inside itself", name)
+		}
+		seen[container] = true
+		value, err := app.widget(container)
+		if err != nil {
+			return fmt.Errorf("parent: %v", err)
+		}
+		widgetType, _ := value["widget"].(string)
+		if schema, _ := app.lookupWidget(widgetType); !schema.Container {
+			return fmt.Errorf("parent: %s is a %s, not a container", container, widgetType)
+		}
+	}
+	return nil
+}
+
+// children returns the names of the widgets placed directly in parent,
+// sorted.
+func (app *AppParser) children(parent string) []string {
+	var names []string
+	for _, key := range app.sortedKeys() {
+		if _, isWidget := app.MapBuild[key]["row"]; isWidget && parentOf(app.MapBuild[key]) == parent {
+			names = append(names, key)
+		}
+	}
+	return names
+}
+
+// parentOf returns the container a widget is placed in, empty for the
+// window.
+func parentOf(widget map[string]interface{}) string {
+	parent, _ := widget["parent"].(string)
+	return parent
+}
+
+// referenceOptions returns the options of a widget that name a Python
+// identifier: its handlers and variables.
+func (app *AppParser) referenceOptions(widget map[string]interface{}) []string {
+	widgetType, _ := widget["widget"].(string)
+	schema, _ := app.lookupWidget(widgetType)
+	var names []string
+	for _, option := range schema.Options {
+		_, isHandler := schema.HandlerSignatures()[option.Name]
+		if option.Type == IdentifierOption || isHandler {
+			names = append(names, option.Name)
+		}
+	}
+	return names
+}
+
+// uniqueName derives an unused widget name from name by numbering it,
+// e.g. button -> button2 and entry2 -> entry3.
+func (app *AppParser) uniqueName(name string) string {
+	base := strings.TrimRight(name, "0123456789")
+	index := 2
+	if number, err := strconv.Atoi(name[len(base):]); err == nil {
+		index = number + 1
+	}
+	for {
+		candidate := fmt.Sprintf("%s%d", base, index)
+		if _, exists := app.MapBuild[candidate]; !exists {
+			return candidate
+		}
+		index++
+	}
+}
+
+// renameReference rewrites a handler or variable name that was derived
+// from a widget name; any other reference is returned unchanged.
+func renameReference(reference, oldName, newName string) string {
+	if reference == oldName {
+		return newName
+	}
+	if strings.HasPrefix(reference, oldName+"_") {
+		return newName + reference[len(oldName):]
+	}
+	return reference
+}
+
+// intAttr reads a numeric widget attribute, which is stored as a string
+// when it comes from the designer or a project file.
+func intAttr(widget map[string]interface{}, attr string) (int, bool) {
+	switch value := widget[attr].(type) {
+	case int:
+		return value, value != -1
+	case float64:
+		return int(value), value != -1
+	case string:
+		number, err := strconv.Atoi(strings.TrimSpace(value))
+		return number, err == nil && number != -1
+	}
+	return 0, false
+}
+
+// isUnset reports whether an attribute value stands for an option the
+// widget doesn't set: empty, or -1, which a project file holds as a number.
+func isUnset(value interface{}) bool {
+	switch value := value.(type) {
+	case string:
+		return len(value) < 1
+	case int:
+		return value == -1
+	case float64:
+		return value == -1
+	}
+	return false
+}
+
+// sizeAttr reads a numeric widget attribute such as padding, which is 0
+// when it isn't set.
+func sizeAttr(widget map[string]interface{}, attr string) int {
+	if size, isSet := intAttr(widget, attr); isSet {
+		return size
+	}
+	return 0
+}
+
+// intArgs parses the integer arguments of a command.
+func intArgs(args []string) ([]int, error) {
+	numbers := make([]int, len(args))
+	for index, arg := range args {
+		number, err := strconv.Atoi(strings.TrimSpace(arg))
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", arg)
+		}
+		numbers[index] = number
+	}
+	return numbers, nil
+}
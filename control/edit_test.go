@@ -0,0 +1,129 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"strings"
+	"testing"
+)
+
+// newTestApp returns an empty project with the given commands applied.
+func newTestApp(t *testing.T, commands ...string) *AppParser {
+	t.Helper()
+	app := &AppParser{}
+	app.setIndent()
+	app.initUserApp()
+	for _, command := range commands {
+		if err := app.ApplyCommand(strings.Split(command, "|$|")); err != nil {
+			t.Fatalf("%s: %v", command, err)
+		}
+	}
+	return app
+}
+
+func TestRenameWidget(t *testing.T) {
+	app := newTestApp(t,
+		"ADD|$|Frame|$|name|@|box|:|row|@|0|:|column|@|0",
+		"ADD|$|Button|$|name|@|ok|:|row|@|0|:|column|@|0|:|parent|@|box|:|command|@|ok_clicked",
+		"ADD|$|Button|$|name|@|also|:|row|@|1|:|column|@|0|:|command|@|ok_clicked",
+		"ADD|$|Button|$|name|@|ok_all|:|row|@|2|:|column|@|0|:|command|@|ok_all_clicked",
+		"ADD|$|Scale|$|name|@|size|:|row|@|3|:|column|@|0|:|command|@|resize",
+		"RENAME|$|ok|:|accept",
+		"RENAME|$|box|:|panel",
+		"RENAME|$|size|:|zoom",
+	)
+	for _, test := range []struct {
+		widget, attr, want string
+	}{
+		{"accept", "command", "accept_clicked"},
+		{"also", "command", "accept_clicked"},
+		{"ok_all", "command", "ok_all_clicked"},
+		{"zoom", "command", "resize"},
+		{"accept", "parent", "panel"},
+	} {
+		if got := app.MapBuild[test.widget][test.attr]; got != test.want {
+			t.Errorf("%s %s = %v, want %s", test.widget, test.attr, got, test.want)
+		}
+	}
+	for _, gone := range []string{"ok", "box", "size"} {
+		if _, exists := app.MapBuild[gone]; exists {
+			t.Errorf("%s still exists", gone)
+		}
+	}
+}
+
+func TestDuplicateWidget(t *testing.T) {
+	app := newTestApp(t,
+		"ADD|$|Frame|$|name|@|box|:|row|@|0|:|column|@|0",
+		"ADD|$|Button|$|name|@|ok2|:|row|@|1|:|column|@|0|:|parent|@|box|:|command|@|ok2_clicked",
+		"DUPLICATE|$|ok2|:|1|:|2",
+	)
+	dup := app.MapBuild["ok3"]
+	for attr, want := range map[string]string{
+		"row": "2", "column": "2", "command": "ok3_clicked", "parent": "box",
+	} {
+		if dup[attr] != want {
+			t.Errorf("ok3 %s = %v, want %s", attr, dup[attr], want)
+		}
+	}
+	if err := app.ApplyCommand([]string{"DUPLICATE", "ok2|:|-2|:|0"}); err == nil {
+		t.Error("duplicated outside the grid")
+	}
+}
+
+func TestMoveWidget(t *testing.T) {
+	setup := []string{
+		"ADD|$|Frame|$|name|@|outer|:|row|@|0|:|column|@|0",
+		"ADD|$|LabelFrame|$|name|@|inner|:|row|@|0|:|column|@|0|:|parent|@|outer",
+		"ADD|$|Label|$|name|@|label|:|row|@|1|:|column|@|0",
+	}
+	for _, test := range []struct {
+		move, parent, err string
+	}{
+		{"label|:|2|:|3", "", ""},
+		{"label|:|0|:|1|:|inner", "inner", ""},
+		{"inner|:|0|:|0|:|", "", ""},
+		{"label|:|0|:|0|:|missing", "", `no widget named "missing"`},
+		{"inner|:|0|:|0|:|label", "", "label is a Label, not a container"},
+		{"outer|:|0|:|0|:|inner", "", "outer can't be placed inside itself"},
+		{"label|:|-1|:|0", "", "must not be negative"},
+		{"label|:|1", "", "expected name|:|row|:|column[|:|parent]"},
+	} {
+		app := newTestApp(t, setup...)
+		err := app.ApplyCommand([]string{"MOVE", test.move})
+		if len(test.err) > 0 {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("MOVE %s: error %v, want %q", test.move, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("MOVE %s: %v", test.move, err)
+			continue
+		}
+		name := strings.Split(test.move, "|:|")[0]
+		if got := parentOf(app.MapBuild[name]); got != test.parent {
+			t.Errorf("MOVE %s: parent %q, want %q", test.move, got, test.parent)
+		}
+	}
+}
+
+func TestContainersGenerateFirst(t *testing.T) {
+	app := newTestApp(t,
+		"ADD|$|Label|$|name|@|early|:|row|@|0|:|column|@|0",
+		"ADD|$|Frame|$|name|@|late|:|row|@|5|:|column|@|0",
+		"MOVE|$|early|:|0|:|0|:|late",
+	)
+	if err := app.ApplyCommand([]string{"REMOVE", "late"}); err == nil {
+		t.Error("removed a container that isn't empty")
+	}
+	app.generate()
+	code := app.Build.String()
+	if strings.Index(code, "self.late = Frame(") > strings.Index(code, "self.early = Label(") {
+		t.Error("the frame is created after the label inside it")
+	}
+	if !strings.Contains(code, "self.early = Label(\n            self.late") {
+		t.Errorf("the label is not created in its frame:\n%s", code)
+	}
+}
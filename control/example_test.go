@@ -0,0 +1,120 @@
+package control_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rootVIII/visipy/control"
+)
+
+func ExampleNewProject() {
+	project := control.NewProject()
+	project.SetTitle("Greeter")
+	project.SetDimensions(240, 80)
+	project.SetStyle(map[string]string{
+		"comments":   "none",
+		"imports":    "explicit",
+		"linelength": "79",
+	})
+	project.AddWidget(control.Widget{
+		Type:    "Label",
+		Name:    "greeting",
+		Options: map[string]string{"text": "Hello!"},
+	})
+	project.AddWidget(control.Widget{
+		Type:    "Button",
+		Name:    "wave",
+		Row:     1,
+		Options: map[string]string{"text": "Wave", "command": "wave_clicked"},
+	})
+	var code bytes.Buffer
+	if err := project.Generate(&code); err != nil {
+		fmt.Println(err)
+		return
+	}
+	// Example output can't hold blank lines in a row, so they are left out.
+	for _, line := range strings.Split(code.String(), "\n") {
+		if len(strings.TrimSpace(line)) > 0 {
+			fmt.Println(line)
+		}
+	}
+	// Output:
+	// from sys import exit
+	// from tkinter import Button, Label, Menu, Tk
+	// from tkinter.ttk import Style
+	// class Greeter:
+	//     def __init__(self, master):
+	//         self.master = master
+	//         self.master.title('Greeter')
+	//         self.master.configure(bg='#000000')
+	//         self.master.geometry('240x80')
+	//         menu = Menu(self.master)
+	//         menu.config(foreground='#d9d9d9', background='#666666')
+	//         self.master.config(menu=menu)
+	//         self.greeting = Label(master, text='Hello!')
+	//         self.greeting.grid(row=0, column=0)
+	//         self.wave = Button(master, text='Wave', command=self.wave_clicked)
+	//         self.wave.grid(row=1, column=0)
+	//     def wave_clicked(self):
+	//         """ TODO: Add handling code here """
+	//         print('Handle wave_clicked here')
+	// def quit_():
+	//     exit()
+	// def run_gui():
+	//     root = Tk()
+	//     root.style = Style()
+	//     root.style.theme_use('default')
+	//     Greeter(root)
+	//     root.mainloop()
+	// if __name__ == '__main__':
+	//     run_gui()
+}
+
+func ExampleProject_AddWidget() {
+	project := control.NewProject()
+	err := project.AddWidget(control.Widget{
+		Type:    "Scale",
+		Name:    "volume",
+		Options: map[string]string{"orient": "SIDEWAYS"},
+	})
+	fmt.Println(err)
+
+	project.AddWidget(control.Widget{Type: "Entry", Name: "first"})
+	project.AddWidget(control.Widget{Type: "Entry", Name: "second"})
+	for _, warning := range project.Lint() {
+		fmt.Println(warning)
+	}
+	// Output:
+	// volume: orient: allowable values: HORIZONTAL, VERTICAL
+	// first: overlaps second at row 0, column 0
+}
+
+func ExampleOpenProject() {
+	dir, err := ioutil.TempDir("", "visipy")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.py.project")
+
+	project := control.NewProject()
+	project.AddMenu("File", "Open", "Quit")
+	if err := project.Save(path); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	opened, err := control.OpenProject(path)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(opened.Remove("File"), opened.Remove("File"))
+	// Output:
+	// <nil> no widget or menu named "File"
+}
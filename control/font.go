@@ -0,0 +1,143 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Font is a structured Tk font description.
+type Font struct {
+	Family     string
+	Size       int // points, or pixels when negative; 0 is Tk's default
+	Weight     string
+	Slant      string
+	Underline  bool
+	Overstrike bool
+}
+
+// namedFonts are Tk's standard named fonts, which are valid as families
+// even though the interpreter doesn't list them.
+var namedFonts = []string{
+	"TkDefaultFont", "TkTextFont", "TkFixedFont", "TkMenuFont",
+	"TkHeadingFont", "TkCaptionFont", "TkSmallCaptionFont", "TkIconFont",
+	"TkTooltipFont",
+}
+
+// ParseFont reads a Tk style font description such as "Courier 12",
+// "{DejaVu Sans} 10 bold italic" or "DejaVu Sans 10 underline". Words
+// before the size make up the family; words after it are styles.
+func ParseFont(spec string) (Font, error) {
+	font := Font{Weight: "normal", Slant: "roman"}
+	spec = strings.TrimSpace(spec)
+	var words []string
+	if strings.HasPrefix(spec, "{") {
+		end := strings.Index(spec, "}")
+		if end < 0 {
+			return Font{}, fmt.Errorf("unbalanced braces in font %q", spec)
+		}
+		font.Family = strings.TrimSpace(spec[1:end])
+		words = strings.Fields(spec[end+1:])
+		if len(words) > 0 {
+			size, err := strconv.Atoi(words[0])
+			if err != nil {
+				return Font{}, fmt.Errorf("font size %q is not an integer", words[0])
+			}
+			font.Size, words = size, words[1:]
+		}
+	} else {
+		fields := strings.Fields(spec)
+		for index, field := range fields {
+			if size, err := strconv.Atoi(field); err == nil {
+				font.Size, words = size, fields[index+1:]
+				break
+			}
+			if len(font.Family) > 0 {
+				font.Family += " "
+			}
+			font.Family += field
+		}
+	}
+	if len(font.Family) < 1 {
+		return Font{}, fmt.Errorf("font %q has no family", spec)
+	}
+
+	for _, style := range words {
+		switch strings.ToLower(style) {
+		case "normal", "bold":
+			font.Weight = strings.ToLower(style)
+		case "roman", "italic":
+			font.Slant = strings.ToLower(style)
+		case "underline":
+			font.Underline = true
+		case "overstrike":
+			font.Overstrike = true
+		default:
+			return Font{}, fmt.Errorf("unknown font style %q, use bold, italic, underline or overstrike", style)
+		}
+	}
+	return font, nil
+}
+
+func (font Font) styles() []string {
+	var styles []string
+	if font.Weight == "bold" {
+		styles = append(styles, "bold")
+	}
+	if font.Slant == "italic" {
+		styles = append(styles, "italic")
+	}
+	if font.Underline {
+		styles = append(styles, "underline")
+	}
+	if font.Overstrike {
+		styles = append(styles, "overstrike")
+	}
+	return styles
+}
+
+// String returns the canonical Tk description the project stores.
+func (font Font) String() string {
+	parts := []string{font.Family}
+	if strings.ContainsAny(font.Family, " \t") {
+		parts[0] = "{" + font.Family + "}"
+	}
+	parts = append(parts, strconv.Itoa(font.Size))
+	return strings.Join(append(parts, font.styles()...), " ")
+}
+
+// Python returns the font as a Python tuple for a widget's font option.
+func (font Font) Python() string {
+	parts := []string{pythonString(font.Family)}
+	if font.Size == 0 && len(font.styles()) < 1 {
+		return "(" + parts[0] + ",)"
+	}
+	parts = append(parts, strconv.Itoa(font.Size))
+	for _, style := range font.styles() {
+		parts = append(parts, pythonString(style))
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+// checkFontFamily refuses families the target interpreter doesn't have,
+// when its families could be probed.
+func (app *AppParser) checkFontFamily(font Font) error {
+	if len(app.Capabilities.Fonts) < 1 || contains(namedFonts, font.Family) {
+		return nil
+	}
+	for _, family := range app.Capabilities.Fonts {
+		if strings.EqualFold(family, font.Family) {
+			return nil
+		}
+	}
+	return &OptionError{"font", fmt.Sprintf("family %q is not installed, see 'Available Fonts'", font.Family)}
+}
+
+// pythonString quotes a value as a single-quoted Python string literal.
+func pythonString(value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	return "'" + strings.Replace(value, "'", `\'`, -1) + "'"
+}
@@ -0,0 +1,58 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import "testing"
+
+func TestParseFont(t *testing.T) {
+	for _, test := range []struct {
+		spec   string
+		want   Font
+		err    string
+		canon  string
+		python string
+	}{
+		{"Courier 12", Font{Family: "Courier", Size: 12, Weight: "normal", Slant: "roman"}, "",
+			"Courier 12", "('Courier', 12)"},
+		{"Courier", Font{Family: "Courier", Weight: "normal", Slant: "roman"}, "",
+			"Courier 0", "('Courier',)"},
+		{"Courier -14", Font{Family: "Courier", Size: -14, Weight: "normal", Slant: "roman"}, "",
+			"Courier -14", "('Courier', -14)"},
+		{"{DejaVu Sans} 10 bold italic", Font{Family: "DejaVu Sans", Size: 10, Weight: "bold", Slant: "italic"}, "",
+			"{DejaVu Sans} 10 bold italic", "('DejaVu Sans', 10, 'bold', 'italic')"},
+		{"DejaVu Sans 10 Underline overstrike", Font{Family: "DejaVu Sans", Size: 10, Weight: "normal", Slant: "roman",
+			Underline: true, Overstrike: true}, "",
+			"{DejaVu Sans} 10 underline overstrike", "('DejaVu Sans', 10, 'underline', 'overstrike')"},
+		{"{Comic Sans}", Font{Family: "Comic Sans", Weight: "normal", Slant: "roman"}, "",
+			"{Comic Sans} 0", "('Comic Sans',)"},
+		{"Times 0 bold", Font{Family: "Times", Weight: "bold", Slant: "roman"}, "",
+			"Times 0 bold", "('Times', 0, 'bold')"},
+		{"O'Neil 9", Font{Family: "O'Neil", Size: 9, Weight: "normal", Slant: "roman"}, "",
+			"O'Neil 9", `('O\'Neil', 9)`},
+		{"", Font{}, `font "" has no family`, "", ""},
+		{"12 bold", Font{}, `font "12 bold" has no family`, "", ""},
+		{"{} 12", Font{}, `font "{} 12" has no family`, "", ""},
+		{"{DejaVu Sans 10", Font{}, `unbalanced braces in font "{DejaVu Sans 10"`, "", ""},
+		{"{DejaVu Sans} big", Font{}, `font size "big" is not an integer`, "", ""},
+		{"Courier 12 heavy", Font{}, `unknown font style "heavy", use bold, italic, underline or overstrike`, "", ""},
+	} {
+		font, err := ParseFont(test.spec)
+		if font != test.want || errorText(err) != test.err {
+			t.Errorf("ParseFont(%q) = %+v, %q; want %+v, %q", test.spec, font, errorText(err), test.want, test.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got := font.String(); got != test.canon {
+			t.Errorf("%q: String() = %q, want %q", test.spec, got, test.canon)
+		}
+		if got := font.Python(); got != test.python {
+			t.Errorf("%q: Python() = %q, want %q", test.spec, got, test.python)
+		}
+		if again, err := ParseFont(font.String()); err != nil || again != font {
+			t.Errorf("%q: String() doesn't parse back: %+v, %v", test.spec, again, err)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The grid edits apply to the grid of parent, a container widget, or of
+// the window when parent is empty.
+
+// InsertRow inserts an empty grid row at index, moving later rows down.
+func (app *AppParser) InsertRow(index int, parent string) error {
+	return app.insertLine("row", "rowspan", index, parent)
+}
+
+// DeleteRow deletes the grid row at index, moving later rows up.
+func (app *AppParser) DeleteRow(index int, parent string) error {
+	return app.deleteLine("row", "rowspan", index, parent)
+}
+
+// InsertColumn inserts an empty grid column at index, moving later columns right.
+func (app *AppParser) InsertColumn(index int, parent string) error {
+	return app.insertLine("column", "columnspan", index, parent)
+}
+
+// DeleteColumn deletes the grid column at index, moving later columns left.
+func (app *AppParser) DeleteColumn(index int, parent string) error {
+	return app.deleteLine("column", "columnspan", index, parent)
+}
+
+// insertLine shifts widgets at or past index by one and widens the span
+// of widgets that straddle it.
+func (app *AppParser) insertLine(cellAttr, spanAttr string, index int, parent string) error {
+	if err := app.checkGrid(cellAttr, index, parent); err != nil {
+		return err
+	}
+	widgets := app.widgetsIn(parent)
+	for name, value := range widgets {
+		if cell, span, placed := gridCell(value, cellAttr, spanAttr); placed && cell+span > index {
+			if err := checkCell(cellAttr, cell+1, span); err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+		}
+	}
+	for _, value := range widgets {
+		cell, span, placed := gridCell(value, cellAttr, spanAttr)
+		if !placed {
+			continue
+		}
+		if cell >= index {
+			value[cellAttr] = strconv.Itoa(cell + 1)
+		} else if cell+span > index {
+			value[spanAttr] = strconv.Itoa(span + 1)
+		}
+	}
+	return nil
+}
+
+// deleteLine narrows the span of widgets that straddle line index and
+// shifts widgets past it back by one. A line that some widget sits only
+// in isn't deleted; those widgets have to be moved or removed first.
+func (app *AppParser) deleteLine(cellAttr, spanAttr string, index int, parent string) error {
+	if err := app.checkGrid(cellAttr, index, parent); err != nil {
+		return err
+	}
+	widgets := app.widgetsIn(parent)
+	var occupants []string
+	for name, value := range widgets {
+		if cell, span, placed := gridCell(value, cellAttr, spanAttr); placed && cell == index && span == 1 {
+			occupants = append(occupants, name)
+		}
+	}
+	if len(occupants) > 0 {
+		sort.Strings(occupants)
+		return fmt.Errorf("%s %d holds %s; move or remove them first",
+			cellAttr, index, strings.Join(occupants, ", "))
+	}
+
+	for _, value := range widgets {
+		cell, span, placed := gridCell(value, cellAttr, spanAttr)
+		switch {
+		case !placed || cell+span <= index:
+		case cell > index:
+			value[cellAttr] = strconv.Itoa(cell - 1)
+		default:
+			value[spanAttr] = strconv.Itoa(span - 1)
+		}
+	}
+	return nil
+}
+
+// widgets returns the project entries that are placed on the grid.
+func (app *AppParser) widgets() map[string]map[string]interface{} {
+	placed := make(map[string]map[string]interface{})
+	for key, value := range app.MapBuild {
+		if _, isWidget := value["row"]; isWidget {
+			placed[key] = value
+		}
+	}
+	return placed
+}
+
+// widgetsIn returns the widgets placed directly in parent.
+func (app *AppParser) widgetsIn(parent string) map[string]map[string]interface{} {
+	placed := app.widgets()
+	for name, value := range placed {
+		if parentOf(value) != parent {
+			delete(placed, name)
+		}
+	}
+	return placed
+}
+
+// checkGrid reports whether parent has a grid line at index.
+func (app *AppParser) checkGrid(cellAttr string, index int, parent string) error {
+	if index < 0 {
+		return fmt.Errorf("%s %d is outside the grid", cellAttr, index)
+	}
+	return app.checkParent("", parent)
+}
+
+// gridLimit is one past the last row or column Tk's grid accepts.
+const gridLimit = 10000
+
+// checkCell reports whether a widget's row or column and its span, named
+// by attr, are inside the grid.
+func checkCell(attr string, cell, span int) error {
+	if cell < 0 || cell >= gridLimit {
+		return &OptionError{attr, fmt.Sprintf("must be from 0 to %d", gridLimit-1)}
+	}
+	if span < 1 || cell+span > gridLimit {
+		return &OptionError{attr + "span", fmt.Sprintf("must be from 1 to %d", gridLimit-cell)}
+	}
+	return nil
+}
+
+// gridCell returns a widget's row or column, how many it spans, and
+// whether the widget has a row or column at all.
+func gridCell(widget map[string]interface{}, cellAttr, spanAttr string) (int, int, bool) {
+	cell, placed := intAttr(widget, cellAttr)
+	span, isSet := intAttr(widget, spanAttr)
+	if !isSet || span < 1 {
+		span = 1
+	}
+	return cell, span, placed
+}
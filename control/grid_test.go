@@ -0,0 +1,84 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestGridLines(t *testing.T) {
+	// Widgets a (0,0), wide (1,0) spanning columns 0-2, tall (0,3)
+	// spanning rows 0-1, and c (2,1) in the window; inner (0,0) in box.
+	setup := []string{
+		"ADD|$|Frame|$|name|@|box|:|row|@|3|:|column|@|0",
+		"ADD|$|Label|$|name|@|a|:|row|@|0|:|column|@|0",
+		"ADD|$|Label|$|name|@|wide|:|row|@|1|:|column|@|0|:|columnspan|@|3",
+		"ADD|$|Label|$|name|@|tall|:|row|@|0|:|column|@|3|:|rowspan|@|2",
+		"ADD|$|Label|$|name|@|c|:|row|@|2|:|column|@|1",
+		"ADD|$|Label|$|name|@|inner|:|row|@|0|:|column|@|0|:|parent|@|box",
+	}
+	for _, test := range []struct {
+		command string
+		want    string // name row+span column+span of each widget, or an error
+	}{
+		{"INSERTROW|$|0",
+			"a 1+1 0+1, box 4+1 0+1, c 3+1 1+1, inner 0+1 0+1, tall 1+2 3+1, wide 2+1 0+3"},
+		{"INSERTROW|$|1",
+			"a 0+1 0+1, box 4+1 0+1, c 3+1 1+1, inner 0+1 0+1, tall 0+3 3+1, wide 2+1 0+3"},
+		{"INSERTROW|$|9",
+			"a 0+1 0+1, box 3+1 0+1, c 2+1 1+1, inner 0+1 0+1, tall 0+2 3+1, wide 1+1 0+3"},
+		{"INSERTCOLUMN|$|1",
+			"a 0+1 0+1, box 3+1 0+1, c 2+1 2+1, inner 0+1 0+1, tall 0+2 4+1, wide 1+1 0+4"},
+		{"INSERTCOLUMN|$|0|:|box",
+			"a 0+1 0+1, box 3+1 0+1, c 2+1 1+1, inner 0+1 1+1, tall 0+2 3+1, wide 1+1 0+3"},
+		{"DELETECOLUMN|$|2",
+			"a 0+1 0+1, box 3+1 0+1, c 2+1 1+1, inner 0+1 0+1, tall 0+2 2+1, wide 1+1 0+2"},
+		{"DELETEROW|$|4",
+			"a 0+1 0+1, box 3+1 0+1, c 2+1 1+1, inner 0+1 0+1, tall 0+2 3+1, wide 1+1 0+3"},
+		{"DELETEROW|$|0", "row 0 holds a; move or remove them first"},
+		{"DELETEROW|$|2", "row 2 holds c; move or remove them first"},
+		{"DELETECOLUMN|$|0|:|box", "column 0 holds inner; move or remove them first"},
+		{"DELETECOLUMN|$|1|:|box",
+			"a 0+1 0+1, box 3+1 0+1, c 2+1 1+1, inner 0+1 0+1, tall 0+2 3+1, wide 1+1 0+3"},
+		{"INSERTROW|$|-1", "row -1 is outside the grid"},
+		{"INSERTROW|$|0|:|a", "a is a Label, not a container"},
+		{"INSERTROW|$|0|:|missing", `no widget named "missing"`},
+		{"INSERTROW|$|x", `"x" is not a number`},
+	} {
+		app := newTestApp(t, setup...)
+		err := app.ApplyCommand(strings.Split(test.command, "|$|"))
+		got := ""
+		if err != nil {
+			got = err.Error()
+		} else {
+			var cells []string
+			for _, name := range app.sortedKeys() {
+				value := app.MapBuild[name]
+				if row, rowSpan, placed := gridCell(value, "row", "rowspan"); placed {
+					column, columnSpan, _ := gridCell(value, "column", "columnspan")
+					cells = append(cells, fmt.Sprintf("%s %d+%d %d+%d", name, row, rowSpan, column, columnSpan))
+				}
+			}
+			got = strings.Join(cells, ", ")
+		}
+		if !strings.HasSuffix(got, test.want) {
+			t.Errorf("%s:\n got %s\nwant %s", test.command, got, test.want)
+		}
+	}
+}
+
+func TestGridLinesSkipUnplacedWidgets(t *testing.T) {
+	app := newTestApp(t)
+	app.MapBuild["loose"] = map[string]interface{}{"widget": "Label", "name": "loose", "row": "", "column": ""}
+	for _, command := range []string{"INSERTROW|$|0", "DELETEROW|$|0", "INSERTCOLUMN|$|0", "DELETECOLUMN|$|0"} {
+		if err := app.ApplyCommand(strings.Split(command, "|$|")); err != nil {
+			t.Errorf("%s: %v", command, err)
+		}
+	}
+	if row, column := app.MapBuild["loose"]["row"], app.MapBuild["loose"]["column"]; row != "" || column != "" {
+		t.Errorf("unplaced widget moved to row %v, column %v", row, column)
+	}
+}
@@ -0,0 +1,105 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import "fmt"
+
+// historyLimit bounds the number of project snapshots kept for undo.
+const historyLimit = 50
+
+// undoable lists the commands that change the project and can be undone.
+var undoable = map[string]bool{
+	"ADD":          true,
+	"REMOVE":       true,
+	"RESET":        true,
+	"LOADUSERPROJ": true,
+	"APPCOLOR":     true,
+	"DIMENSIONS":   true,
+	"ICON":         true,
+	"MENU":         true,
+	"THEME":        true,
+	"TITLE":        true,
+	"MENUCOLOR":    true,
+	"RENAME":       true,
+	"DUPLICATE":    true,
+	"MOVE":         true,
+	"INSERTROW":    true,
+	"DELETEROW":    true,
+	"INSERTCOLUMN": true,
+	"DELETECOLUMN": true,
+	"STYLE":        true,
+	"META":         true,
+	"TARGET":       true,
+}
+
+// snapshot is a copy of the project as it was before a command ran.
+// The custom widget types and template overrides are never changed in
+// place, so the snapshot can share them with the controller.
+type snapshot struct {
+	MapBuild  map[string]map[string]interface{}
+	HaveIcon  bool
+	Widgets   map[string]WidgetSchema
+	Templates map[string]string
+}
+
+func (app *AppParser) takeSnapshot() snapshot {
+	return snapshot{
+		MapBuild:  copyBuild(app.MapBuild),
+		HaveIcon:  app.HaveIcon,
+		Widgets:   app.projectWidgets,
+		Templates: app.projectTemplates,
+	}
+}
+
+func (app *AppParser) restoreSnapshot(snap snapshot) {
+	app.MapBuild = snap.MapBuild
+	app.HaveIcon = snap.HaveIcon
+	app.projectWidgets = snap.Widgets
+	app.projectTemplates = snap.Templates
+}
+
+// pushHistory records the project as it was before a change and drops
+// anything that could previously have been redone.
+func (app *AppParser) pushHistory(before snapshot) {
+	app.undo = append(app.undo, before)
+	if len(app.undo) > historyLimit {
+		app.undo = app.undo[len(app.undo)-historyLimit:]
+	}
+	app.redo = nil
+}
+
+// Undo reverts the project to the state before the last change.
+func (app *AppParser) Undo() error {
+	if len(app.undo) < 1 {
+		return fmt.Errorf("nothing to undo")
+	}
+	app.redo = append(app.redo, app.takeSnapshot())
+	app.restoreSnapshot(app.undo[len(app.undo)-1])
+	app.undo = app.undo[:len(app.undo)-1]
+	return nil
+}
+
+// Redo re-applies the last change reverted by Undo.
+func (app *AppParser) Redo() error {
+	if len(app.redo) < 1 {
+		return fmt.Errorf("nothing to redo")
+	}
+	app.undo = append(app.undo, app.takeSnapshot())
+	app.restoreSnapshot(app.redo[len(app.redo)-1])
+	app.redo = app.redo[:len(app.redo)-1]
+	return nil
+}
+
+// copyBuild copies a project map deeply enough that later edits to either
+// copy are not shared; the innermost values are all scalars.
+func copyBuild(build map[string]map[string]interface{}) map[string]map[string]interface{} {
+	dup := make(map[string]map[string]interface{}, len(build))
+	for key, value := range build {
+		dup[key] = make(map[string]interface{}, len(value))
+		for innerKey, innerValue := range value {
+			dup[key][innerKey] = innerValue
+		}
+	}
+	return dup
+}
@@ -0,0 +1,169 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Names the generated code may use unqualified, by the module they are
+// imported from. Names ttk shares with tkinter come from tkinter.
+var (
+	tkinterNames = nameSet(`
+		BitmapImage BooleanVar Button Canvas Checkbutton DoubleVar Entry Frame
+		IntVar Label LabelFrame Listbox Menu Menubutton Message OptionMenu
+		PanedWindow PhotoImage Radiobutton Scale Scrollbar Spinbox StringVar
+		Text Tk Toplevel
+		ACTIVE ALL ANCHOR BOTH BOTTOM BROWSE CENTER CHAR DISABLED DOTBOX E END
+		EXTENDED FLAT GROOVE HIDDEN HORIZONTAL INSERT LEFT MULTIPLE N NE NO
+		NONE NORMAL NS NSEW NW RAISED RIDGE RIGHT S SE SINGLE SOLID SUNKEN SW
+		TOP UNDERLINE VERTICAL W WORD X Y YES`)
+	ttkNames = nameSet(`
+		Combobox LabeledScale Notebook Progressbar Separator Sizegrip Style
+		Treeview`)
+	tkinterModules = nameSet(`
+		colorchooser commondialog dialog filedialog font messagebox
+		scrolledtext simpledialog ttk`)
+	sysNames = nameSet(`argv exit stderr stdin stdout`)
+)
+
+func nameSet(names string) map[string]bool {
+	set := map[string]bool{}
+	for _, name := range strings.Fields(names) {
+		set[name] = true
+	}
+	return set
+}
+
+var (
+	pythonName = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+	fromImport = regexp.MustCompile(`^from\s+([\w.]+)\s+import\s+(.+)$`)
+)
+
+// importLines chooses the import statements for the generated code, given
+// the imports custom widgets ask for. With qualified imports, the tkinter
+// and ttk names in body are qualified and the new body is returned.
+func importLines(body string, extra []string, style CodeStyle) ([]string, string) {
+	if style.Imports == "star" {
+		return append([]string{"from sys import exit", "from tkinter.ttk import Style",
+			"from tkinter import *"}, extra...), body
+	}
+
+	from := map[string]map[string]bool{}
+	var plain []string
+	add := func(module, name string) {
+		if from[module] == nil {
+			from[module] = map[string]bool{}
+		}
+		from[module][name] = true
+	}
+	for _, line := range extra {
+		if match := fromImport.FindStringSubmatch(line); match != nil {
+			for _, name := range strings.Split(strings.Trim(match[2], "()"), ",") {
+				add(match[1], strings.TrimSpace(name))
+			}
+		} else if !contains(plain, line) {
+			plain = append(plain, line)
+		}
+	}
+
+	var styled strings.Builder
+	for _, segment := range splitPython(body) {
+		if segment.kind != 'c' {
+			styled.WriteString(segment.text)
+			continue
+		}
+		text := segment.text
+		last := 0
+		for _, match := range pythonName.FindAllStringIndex(text, -1) {
+			name, before := text[match[0]:match[1]], text[:match[0]]
+			if strings.HasSuffix(before, ".") || strings.HasSuffix(before, "def ") ||
+				strings.HasSuffix(before, "class ") || isKeywordArgument(text[match[1]:]) {
+				continue
+			}
+			qualifier := ""
+			switch {
+			case sysNames[name]:
+				add("sys", name)
+			case tkinterModules[name]:
+				add("tkinter", name)
+			case tkinterNames[name] && style.Imports == "qualified":
+				qualifier = "tk."
+			case tkinterNames[name]:
+				add("tkinter", name)
+			case ttkNames[name] && style.Imports == "qualified":
+				add("tkinter", "ttk")
+				qualifier = "ttk."
+			case ttkNames[name]:
+				add("tkinter.ttk", name)
+			}
+			if len(qualifier) > 0 {
+				styled.WriteString(text[last:match[0]] + qualifier + name)
+				last = match[1]
+			}
+		}
+		styled.WriteString(text[last:])
+	}
+	if style.Imports == "qualified" && styled.String() != body {
+		plain = append(plain, "import tkinter as tk")
+	}
+
+	// Like isort: plain imports first, then from imports, each sorted by
+	// module.
+	sort.Strings(plain)
+	lines := plain
+	var modules []string
+	for module := range from {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+	for _, module := range modules {
+		var names []string
+		for name := range from[module] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		lines = append(lines, wrapImport(module, names, style))
+	}
+	return lines, styled.String()
+}
+
+// isKeywordArgument reports whether the code after a name makes it the
+// keyword of an argument, as in text='OK'.
+func isKeywordArgument(after string) bool {
+	after = strings.TrimLeft(after, " ")
+	return strings.HasPrefix(after, "=") && !strings.HasPrefix(after, "==")
+}
+
+// wrapImport writes a from import, in parentheses over several lines when
+// it is longer than the style's line length (79 when unset).
+func wrapImport(module string, names []string, style CodeStyle) string {
+	line := "from " + module + " import " + strings.Join(names, ", ")
+	limit := style.LineLength
+	if limit < 1 {
+		limit = 79
+	}
+	if len(line) <= limit {
+		return line
+	}
+	indent := strings.Repeat(" ", style.Indent)
+	wrapped := "from " + module + " import ("
+	current := indent
+	for index, name := range names {
+		if index < len(names)-1 {
+			name += ","
+		}
+		if len(current) > len(indent) && len(current)+1+len(name) > limit {
+			wrapped += "\n" + current
+			current = indent
+		}
+		if len(current) > len(indent) {
+			current += " "
+		}
+		current += name
+	}
+	return wrapped + "\n" + current + "\n)"
+}
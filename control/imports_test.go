@@ -0,0 +1,88 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestImportLines(t *testing.T) {
+	const body = "root = Tk()\n" +
+		"self.ok = Button(master, text='Label', anchor=W)  # Entry\n" +
+		"self.style = Style()\n" +
+		"self.Label = messagebox.showinfo(command=exit)\n"
+	for _, test := range []struct {
+		name    string
+		imports string
+		extra   []string
+		want    []string
+		body    string
+	}{
+		{"star", "star", []string{"from tkinter.ttk import Combobox"}, []string{
+			"from sys import exit",
+			"from tkinter.ttk import Style",
+			"from tkinter import *",
+			"from tkinter.ttk import Combobox",
+		}, body},
+		{"explicit", "explicit", nil, []string{
+			"from sys import exit",
+			"from tkinter import Button, Tk, W, messagebox",
+			"from tkinter.ttk import Style",
+		}, body},
+		{"qualified", "qualified", nil, []string{
+			"import tkinter as tk",
+			"from sys import exit",
+			"from tkinter import messagebox, ttk",
+		}, "root = tk.Tk()\n" +
+			"self.ok = tk.Button(master, text='Label', anchor=tk.W)  # Entry\n" +
+			"self.style = ttk.Style()\n" +
+			"self.Label = messagebox.showinfo(command=exit)\n"},
+		{"custom widget imports", "explicit", []string{
+			"import numpy",
+			"from tkinter.ttk import (Combobox, Notebook)",
+			"import numpy",
+			"from tkinter import Tk",
+		}, []string{
+			"import numpy",
+			"from sys import exit",
+			"from tkinter import Button, Tk, W, messagebox",
+			"from tkinter.ttk import Combobox, Notebook, Style",
+		}, body},
+	} {
+		lines, got := importLines(body, test.extra, CodeStyle{Indent: 4, Imports: test.imports})
+		if !reflect.DeepEqual(lines, test.want) {
+			t.Errorf("%s: imports\n%q\nwant\n%q", test.name, lines, test.want)
+		}
+		if got != test.body {
+			t.Errorf("%s: body\n%s\nwant\n%s", test.name, got, test.body)
+		}
+	}
+}
+
+func TestImportLinesIgnoresDefinitions(t *testing.T) {
+	lines, _ := importLines("def Tk(self):\n    pass\nclass Button:\n    x == Label\n",
+		nil, CodeStyle{Indent: 4, Imports: "explicit"})
+	if want := []string{"from tkinter import Label"}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("imports %q, want %q", lines, want)
+	}
+}
+
+func TestWrapImport(t *testing.T) {
+	names := []string{"Button", "Checkbutton", "Entry", "Label", "Listbox", "Radiobutton"}
+	for _, test := range []struct {
+		style CodeStyle
+		want  string
+	}{
+		{CodeStyle{Indent: 4}, "from tkinter import Button, Checkbutton, Entry, Label, Listbox, Radiobutton"},
+		{CodeStyle{Indent: 4, LineLength: 40},
+			"from tkinter import (\n    Button, Checkbutton, Entry, Label,\n    Listbox, Radiobutton\n)"},
+		{CodeStyle{Indent: 2, LineLength: 20},
+			"from tkinter import (\n  Button,\n  Checkbutton,\n  Entry, Label,\n  Listbox,\n  Radiobutton\n)"},
+	} {
+		if got := wrapImport("tkinter", names, test.style); got != test.want {
+			t.Errorf("line length %d:\n got %q\nwant %q", test.style.LineLength, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"image"
+	_ "image/gif" // decode image widget sizes
+	_ "image/png" // decode image widget sizes
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Placement is a widget's grid cell and its approximate size in pixels,
+// padding included.
+type Placement struct {
+	Name       string
+	Widget     string
+	Row        int
+	Column     int
+	RowSpan    int
+	ColumnSpan int
+	Width      int
+	Height     int
+}
+
+// GridLayout approximates how Tk's grid manager lays out a project.
+type GridLayout struct {
+	Placements   []Placement
+	ColumnWidths []int
+	RowHeights   []int
+	WindowWidth  int
+	WindowHeight int
+}
+
+// ColumnOffset returns the x position where a grid column starts.
+func (grid GridLayout) ColumnOffset(column int) int {
+	return sumTo(grid.ColumnWidths, column)
+}
+
+// RowOffset returns the y position where a grid row starts.
+func (grid GridLayout) RowOffset(row int) int {
+	return sumTo(grid.RowHeights, row)
+}
+
+// NewGridLayout places the widgets in a project's window on its grid,
+// sorted by row, column and name, and estimates the size of each row and
+// column. Containers are sized by the grid of the widgets inside them.
+func NewGridLayout(project map[string]map[string]interface{}) GridLayout {
+	grid := containerLayout(project, "")
+	grid.WindowWidth, grid.WindowHeight = parseDimensions(project)
+	return grid
+}
+
+// containerLayout lays out the grid of a container widget the way
+// NewGridLayout lays out the window's, which is the container "".
+func containerLayout(project map[string]map[string]interface{}, container string) GridLayout {
+	var grid GridLayout
+	for name, value := range project {
+		if _, isWidget := value["row"]; !isWidget || parentOf(value) != container {
+			continue
+		}
+		row, rowSpan, hasRow := gridCell(value, "row", "rowspan")
+		column, columnSpan, hasColumn := gridCell(value, "column", "columnspan")
+		if !hasRow || !hasColumn || checkCell("row", row, rowSpan) != nil ||
+			checkCell("column", column, columnSpan) != nil {
+			continue
+		}
+		width, height := estimateSize(value, containerLayout(project, name))
+		widget, _ := value["widget"].(string)
+		grid.Placements = append(grid.Placements, Placement{
+			Name: name, Widget: widget,
+			Row: row, Column: column, RowSpan: rowSpan, ColumnSpan: columnSpan,
+			Width: width, Height: height,
+		})
+	}
+	sort.Slice(grid.Placements, func(i, j int) bool {
+		a, b := grid.Placements[i], grid.Placements[j]
+		if a.Row != b.Row {
+			return a.Row < b.Row
+		}
+		if a.Column != b.Column {
+			return a.Column < b.Column
+		}
+		return a.Name < b.Name
+	})
+
+	for _, cell := range grid.Placements {
+		grid.ColumnWidths = grow(grid.ColumnWidths, cell.Column+cell.ColumnSpan)
+		grid.RowHeights = grow(grid.RowHeights, cell.Row+cell.RowSpan)
+		if cell.ColumnSpan == 1 && cell.Width > grid.ColumnWidths[cell.Column] {
+			grid.ColumnWidths[cell.Column] = cell.Width
+		}
+		if cell.RowSpan == 1 && cell.Height > grid.RowHeights[cell.Row] {
+			grid.RowHeights[cell.Row] = cell.Height
+		}
+	}
+
+	// Like Tk, give spanning widgets that don't fit the extra space in
+	// the last row or column they cover.
+	for _, cell := range grid.Placements {
+		last := cell.Column + cell.ColumnSpan - 1
+		if extra := cell.Width - (grid.ColumnOffset(last+1) - grid.ColumnOffset(cell.Column)); extra > 0 {
+			grid.ColumnWidths[last] += extra
+		}
+		last = cell.Row + cell.RowSpan - 1
+		if extra := cell.Height - (grid.RowOffset(last+1) - grid.RowOffset(cell.Row)); extra > 0 {
+			grid.RowHeights[last] += extra
+		}
+	}
+	return grid
+}
+
+// parseDimensions reads the window size from the DIMENSIONS setting.
+func parseDimensions(project map[string]map[string]interface{}) (int, int) {
+	dimensions, _ := project["DIMENSIONS"]["dimensions"].(string)
+	xy := strings.Split(strings.TrimSpace(dimensions), "x")
+	if len(xy) != 2 {
+		return 0, 0
+	}
+	width, _ := strconv.Atoi(xy[0])
+	height, _ := strconv.Atoi(xy[1])
+	return clampSize(width), clampSize(height)
+}
+
+// fontMetrics approximates the average character width and line height
+// in pixels of a widget's font; Tk's default font is about 9 points.
+func fontMetrics(widget map[string]interface{}) (int, int) {
+	size := 9
+	spec, _ := widget["font"].(string)
+	if font, err := ParseFont(spec); err == nil && font.Size != 0 {
+		size = font.Size
+		if size < 0 {
+			size = -size * 3 / 4
+		}
+	}
+	// Tiny sizes such as Courier -1 still take a pixel per character.
+	return maxInt((size*4+4)/5, 1), maxInt(size*2-1, 1)
+}
+
+// Width returns the width in pixels of all the grid's columns.
+func (grid GridLayout) Width() int {
+	return grid.ColumnOffset(len(grid.ColumnWidths))
+}
+
+// Height returns the height in pixels of all the grid's rows.
+func (grid GridLayout) Height() int {
+	return grid.RowOffset(len(grid.RowHeights))
+}
+
+// estimateSize approximates the space in pixels a widget requests from
+// the grid, following Tk's default sizes for each widget type. A container
+// takes the size of content, the grid of the widgets inside it, if any.
+func estimateSize(widget map[string]interface{}, content GridLayout) (int, int) {
+	charWidth, lineHeight := fontMetrics(widget)
+	text, _ := widget["text"].(string)
+	chars, hasWidth := intAttr(widget, "width")
+	lines, hasHeight := intAttr(widget, "height")
+	chars, lines = clampSize(chars), clampSize(lines)
+	if !hasWidth {
+		chars = utf8.RuneCountInString(text)
+	}
+	if !hasHeight {
+		lines = 1
+	}
+
+	var width, height, border int
+	highlight := 1
+	switch widget["widget"] {
+	case "Button":
+		width, height, border = chars*charWidth+16, lines*lineHeight+8, 2
+	case "Checkbutton", "Radiobutton":
+		width, height, border = chars*charWidth+26, lines*lineHeight+4, 0
+	case "Label":
+		width, height, border = chars*charWidth+4, lines*lineHeight+4, 0
+	case "Entry", "Spinbox":
+		if !hasWidth {
+			chars = 20
+		}
+		width, height, border = chars*charWidth+4, lineHeight+4, 1
+	case "Listbox":
+		if !hasWidth {
+			chars = 20
+		}
+		if !hasHeight {
+			lines = 10
+		}
+		width, height, border = chars*charWidth+4, lines*lineHeight+4, 1
+	case "Text":
+		if !hasWidth {
+			chars = 80
+		}
+		if !hasHeight {
+			lines = 24
+		}
+		width, height, border = chars*charWidth+4, lines*lineHeight+4, 1
+	case "Scale":
+		length, hasLength := intAttr(widget, "length")
+		if !hasLength {
+			length = 100
+		}
+		length = clampSize(length)
+		if widget["orient"] == "HORIZONTAL" {
+			width, height = length, lineHeight+30
+		} else {
+			width, height = lineHeight*3+20, length
+		}
+		border = 1
+	case "Image":
+		width, height = imageSize(widget)
+	case "Frame", "LabelFrame":
+		// Frames are sized in pixels rather than characters.
+		width, height, highlight = 0, 0, 0
+		if hasWidth {
+			width = chars
+		}
+		if hasHeight {
+			height = lines
+		}
+		if len(content.Placements) > 0 {
+			width, height = content.Width(), content.Height()
+		}
+		if widget["widget"] == "LabelFrame" {
+			border = 2
+			if len(text) > 0 {
+				width = maxInt(width, utf8.RuneCountInString(text)*charWidth+16)
+				height += lineHeight
+			}
+		}
+	default:
+		width, height = chars*charWidth+4, lines*lineHeight+4
+		if len(content.Placements) > 0 {
+			width, height = content.Width(), content.Height()
+		}
+	}
+
+	if borderwidth, isSet := intAttr(widget, "borderwidth"); isSet {
+		border = clampSize(borderwidth)
+	}
+	if thickness, isSet := intAttr(widget, "highlightthickness"); isSet {
+		highlight = clampSize(thickness)
+	}
+	padx, pady := clampSize(sizeAttr(widget, "padx")), clampSize(sizeAttr(widget, "pady"))
+	frame := 2 * (border + highlight)
+	return clampSize(width + frame + 2*padx), clampSize(height + frame + 2*pady)
+}
+
+// maxSize bounds the sizes, in pixels or characters, that the layout works
+// with, so that a size no screen could show can't overflow a grid's sums.
+const maxSize = 1 << 16
+
+// clampSize limits a size to 0 through maxSize.
+func clampSize(size int) int {
+	if size < 0 {
+		return 0
+	}
+	if size > maxSize {
+		return maxSize
+	}
+	return size
+}
+
+// imageSize reads the size of an image widget's file.
+func imageSize(widget map[string]interface{}) (int, int) {
+	path, _ := widget["image"].(string)
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, 0
+	}
+	defer file.Close()
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return 0, 0
+	}
+	return config.Width, config.Height
+}
+
+func grow(sizes []int, length int) []int {
+	for len(sizes) < length {
+		sizes = append(sizes, 0)
+	}
+	return sizes
+}
+
+func sumTo(sizes []int, end int) int {
+	total := 0
+	for index := 0; index < end && index < len(sizes); index++ {
+		total += sizes[index]
+	}
+	return total
+}
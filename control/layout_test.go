@@ -0,0 +1,114 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewGridLayout(t *testing.T) {
+	// With the default font a character is 8px wide and a line 17px high;
+	// a Button adds 16x8 of padding and 6 of border and highlight, a Label
+	// 4x4 and 2.
+	for _, test := range []struct {
+		name             string
+		commands         []string
+		widths, heights  []int
+		placements       int
+		windowW, windowH int
+	}{
+		{"empty", nil, nil, nil, 0, 300, 400},
+		{"button", []string{
+			"ADD|$|Button|$|name|@|ok|:|row|@|0|:|column|@|0|:|text|@|OK",
+		}, []int{38}, []int{31}, 1, 300, 400},
+		{"padding", []string{
+			"ADD|$|Button|$|name|@|ok|:|row|@|1|:|column|@|1|:|text|@|OK|:|padx|@|5|:|pady|@|2",
+		}, []int{0, 48}, []int{0, 35}, 1, 300, 400},
+		{"span grows the last column", []string{
+			"ADD|$|Label|$|name|@|a|:|row|@|0|:|column|@|0|:|text|@|Name",
+			"ADD|$|Label|$|name|@|b|:|row|@|0|:|column|@|1|:|text|@|Name",
+			"ADD|$|Label|$|name|@|c|:|row|@|1|:|column|@|0|:|columnspan|@|2|:|width|@|20",
+		}, []int{38, 128}, []int{23, 23}, 3, 300, 400},
+		{"span that fits", []string{
+			"ADD|$|Label|$|name|@|a|:|row|@|0|:|column|@|0|:|text|@|Name",
+			"ADD|$|Label|$|name|@|b|:|row|@|0|:|column|@|1|:|text|@|Name",
+			"ADD|$|Label|$|name|@|c|:|row|@|1|:|column|@|0|:|columnspan|@|2|:|text|@|ab",
+		}, []int{38, 38}, []int{23, 23}, 3, 300, 400},
+		{"frame takes its content's size", []string{
+			"ADD|$|Frame|$|name|@|box|:|row|@|0|:|column|@|0|:|width|@|200",
+			"ADD|$|Label|$|name|@|a|:|row|@|0|:|column|@|0|:|text|@|Name|:|parent|@|box",
+		}, []int{38}, []int{23}, 1, 300, 400},
+		{"empty frame is sized in pixels", []string{
+			"ADD|$|Frame|$|name|@|box|:|row|@|0|:|column|@|0|:|width|@|200|:|height|@|50",
+		}, []int{200}, []int{50}, 1, 300, 400},
+		{"labelframe adds its label and border", []string{
+			"ADD|$|LabelFrame|$|name|@|box|:|row|@|0|:|column|@|0|:|text|@|Box",
+			"ADD|$|Label|$|name|@|a|:|row|@|0|:|column|@|0|:|text|@|Name|:|parent|@|box",
+		}, []int{44}, []int{44}, 1, 300, 400},
+		{"dimensions", []string{"DIMENSIONS|$|640x480"}, nil, nil, 0, 640, 480},
+		{"bad dimensions", []string{"DIMENSIONS|$|wide"}, nil, nil, 0, 0, 0},
+	} {
+		grid := NewGridLayout(newTestApp(t, test.commands...).MapBuild)
+		if !reflect.DeepEqual(grid.ColumnWidths, test.widths) || !reflect.DeepEqual(grid.RowHeights, test.heights) {
+			t.Errorf("%s: columns %v rows %v, want %v %v",
+				test.name, grid.ColumnWidths, grid.RowHeights, test.widths, test.heights)
+		}
+		if len(grid.Placements) != test.placements {
+			t.Errorf("%s: %d placements, want %d", test.name, len(grid.Placements), test.placements)
+		}
+		if grid.WindowWidth != test.windowW || grid.WindowHeight != test.windowH {
+			t.Errorf("%s: window %dx%d, want %dx%d",
+				test.name, grid.WindowWidth, grid.WindowHeight, test.windowW, test.windowH)
+		}
+	}
+}
+
+func TestNewGridLayoutSkipsCellsPastTheGrid(t *testing.T) {
+	// Loaded projects may hold cells Tk would refuse; they must not make
+	// the layout allocate a row or column for each.
+	project := newTestApp(t, "ADD|$|Label|$|name|@|a|:|row|@|0|:|column|@|0").MapBuild
+	project["far"] = map[string]interface{}{"widget": "Label", "name": "far", "row": "100000000", "column": "0"}
+	project["wide"] = map[string]interface{}{"widget": "Label", "name": "wide", "row": "0", "column": "1", "columnspan": "100000000"}
+	grid := NewGridLayout(project)
+	if len(grid.Placements) != 1 || len(grid.RowHeights) != 1 || len(grid.ColumnWidths) != 1 {
+		t.Errorf("placed %d widgets on %d rows and %d columns, want 1 on 1 and 1",
+			len(grid.Placements), len(grid.RowHeights), len(grid.ColumnWidths))
+	}
+}
+
+func TestValidateGridLimit(t *testing.T) {
+	for _, test := range []struct {
+		attrs string
+		err   string
+	}{
+		{"row|@|9999|:|column|@|0", ""},
+		{"row|@|10000|:|column|@|0", "row: must be from 0 to 9999"},
+		{"row|@|0|:|column|@|-2", "column: must be from 0 to 9999"},
+		{"row|@|9998|:|column|@|0|:|rowspan|@|2", ""},
+		{"row|@|9998|:|column|@|0|:|rowspan|@|3", "rowspan: must be from 1 to 2"},
+		{"row|@|0|:|column|@|0|:|columnspan|@|0", "columnspan: must be from 1 to 10000"},
+	} {
+		app := newTestApp(t)
+		err := app.ApplyCommand([]string{"ADD", "Label", "name|@|a|:|" + test.attrs})
+		if got := errorText(err); got != test.err {
+			t.Errorf("%s: error %q, want %q", test.attrs, got, test.err)
+		}
+	}
+
+	app := newTestApp(t, "ADD|$|Label|$|name|@|a|:|row|@|9999|:|column|@|0")
+	if err := app.ApplyCommand([]string{"INSERTROW", "0"}); err == nil {
+		t.Error("inserted a row that pushed a widget past the grid")
+	}
+	if err := app.ApplyCommand([]string{"MOVE", "a|:|10000|:|0"}); err == nil {
+		t.Error("moved a widget past the grid")
+	}
+}
+
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
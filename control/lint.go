@@ -0,0 +1,133 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LayoutWarning is a problem found in a project's grid layout. Widget is
+// empty for warnings about the grid itself, such as an empty row.
+type LayoutWarning struct {
+	Widget  string
+	Message string
+}
+
+func (warning LayoutWarning) String() string {
+	if len(warning.Widget) < 1 {
+		return warning.Message
+	}
+	return fmt.Sprintf("%s: %s", warning.Widget, warning.Message)
+}
+
+// LintLayout checks a project's grids for widgets sharing a cell, rows and
+// columns left empty, and widgets that don't fit inside the window. The
+// warnings about the grid of a container name the container.
+func LintLayout(project map[string]map[string]interface{}) []LayoutWarning {
+	grid := NewGridLayout(project)
+	warnings := lintGrid(project, grid, "")
+
+	if grid.WindowWidth < 1 || grid.WindowHeight < 1 {
+		return warnings
+	}
+	for _, cell := range grid.Placements {
+		right := grid.ColumnOffset(cell.Column) + cell.Width
+		bottom := grid.RowOffset(cell.Row) + cell.Height
+		var outside []string
+		if right > grid.WindowWidth {
+			outside = append(outside, fmt.Sprintf(
+				"ends near x=%d, past the %dpx window width", right, grid.WindowWidth))
+		}
+		if bottom > grid.WindowHeight {
+			outside = append(outside, fmt.Sprintf(
+				"ends near y=%d, past the %dpx window height", bottom, grid.WindowHeight))
+		}
+		if len(outside) > 0 {
+			warnings = append(warnings, LayoutWarning{cell.Name, strings.Join(outside, " and ")})
+		}
+	}
+	return warnings
+}
+
+// lintGrid checks the grid of a container, and those of the containers in
+// it, for overlapping widgets and empty rows and columns.
+func lintGrid(project map[string]map[string]interface{}, grid GridLayout, container string) []LayoutWarning {
+	var warnings []LayoutWarning
+	for index, cell := range grid.Placements {
+		for _, other := range grid.Placements[index+1:] {
+			row, column, overlaps := overlap(cell, other)
+			if overlaps {
+				warnings = append(warnings, LayoutWarning{cell.Name, fmt.Sprintf(
+					"overlaps %s at row %d, column %d", other.Name, row, column)})
+			}
+		}
+	}
+
+	for _, line := range []struct {
+		name  string
+		sizes []int
+		span  func(Placement) (int, int)
+	}{
+		{"row", grid.RowHeights, func(cell Placement) (int, int) { return cell.Row, cell.RowSpan }},
+		{"column", grid.ColumnWidths, func(cell Placement) (int, int) { return cell.Column, cell.ColumnSpan }},
+	} {
+		used := make([]bool, len(line.sizes))
+		for _, cell := range grid.Placements {
+			start, span := line.span(cell)
+			for index := start; index < start+span; index++ {
+				used[index] = true
+			}
+		}
+		// Runs of empty lines get a single warning.
+		for start := 0; start < len(used); start++ {
+			if used[start] {
+				continue
+			}
+			end := start
+			for end+1 < len(used) && !used[end+1] {
+				end++
+			}
+			message := fmt.Sprintf("%s %d is empty", line.name, start)
+			if end > start {
+				message = fmt.Sprintf("%ss %d-%d are empty", line.name, start, end)
+			}
+			warnings = append(warnings, LayoutWarning{container, message})
+			start = end
+		}
+	}
+
+	for _, cell := range grid.Placements {
+		if inner := containerLayout(project, cell.Name); len(inner.Placements) > 0 {
+			warnings = append(warnings, lintGrid(project, inner, cell.Name)...)
+		}
+	}
+	return warnings
+}
+
+// LintLayout checks the current project's grid layout.
+func (app *AppParser) LintLayout() []LayoutWarning {
+	return LintLayout(app.MapBuild)
+}
+
+// overlap returns the first grid cell two placements share.
+func overlap(a, b Placement) (int, int, bool) {
+	top, bottom := maxInt(a.Row, b.Row), minInt(a.Row+a.RowSpan, b.Row+b.RowSpan)
+	left, right := maxInt(a.Column, b.Column), minInt(a.Column+a.ColumnSpan, b.Column+b.ColumnSpan)
+	return top, left, top < bottom && left < right
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
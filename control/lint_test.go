@@ -0,0 +1,69 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLintLayout(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		commands []string
+		want     []string
+	}{
+		{"clean", []string{
+			"ADD|$|Label|$|name|@|a|:|row|@|0|:|column|@|0|:|text|@|Name",
+		}, nil},
+		{"overlap", []string{
+			"ADD|$|Label|$|name|@|a|:|row|@|0|:|column|@|0|:|text|@|Name",
+			"ADD|$|Label|$|name|@|b|:|row|@|0|:|column|@|0|:|text|@|Name",
+		}, []string{"a: overlaps b at row 0, column 0"}},
+		{"overlap inside a span", []string{
+			"ADD|$|Label|$|name|@|a|:|row|@|0|:|column|@|0|:|columnspan|@|2|:|rowspan|@|2",
+			"ADD|$|Label|$|name|@|b|:|row|@|1|:|column|@|1",
+		}, []string{"a: overlaps b at row 1, column 1"}},
+		{"empty row", []string{
+			"ADD|$|Label|$|name|@|a|:|row|@|0|:|column|@|0",
+			"ADD|$|Label|$|name|@|b|:|row|@|2|:|column|@|0",
+		}, []string{"row 1 is empty"}},
+		{"empty rows and columns", []string{
+			"ADD|$|Label|$|name|@|a|:|row|@|3|:|column|@|2",
+		}, []string{"rows 0-2 are empty", "columns 0-1 are empty"}},
+		{"span fills the line", []string{
+			"ADD|$|Label|$|name|@|a|:|row|@|0|:|column|@|0|:|rowspan|@|2",
+			"ADD|$|Label|$|name|@|b|:|row|@|1|:|column|@|1",
+		}, nil},
+		{"empty line in a container", []string{
+			"ADD|$|Frame|$|name|@|box|:|row|@|0|:|column|@|0",
+			"ADD|$|Label|$|name|@|a|:|row|@|0|:|column|@|1|:|parent|@|box",
+		}, []string{"box: column 0 is empty"}},
+		{"past the window", []string{
+			"DIMENSIONS|$|50x20",
+			"ADD|$|Label|$|name|@|a|:|row|@|0|:|column|@|0|:|width|@|10",
+		}, []string{"a: ends near x=86, past the 50px window width and ends near y=23, past the 20px window height"}},
+		{"no window size", []string{
+			"DIMENSIONS|$|",
+			"ADD|$|Label|$|name|@|a|:|row|@|0|:|column|@|0|:|width|@|1000",
+		}, nil},
+	} {
+		var got []string
+		for _, warning := range newTestApp(t, test.commands...).LintLayout() {
+			got = append(got, warning.String())
+		}
+		if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+			t.Errorf("%s:\n got %q\nwant %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestLintLayoutFarRow(t *testing.T) {
+	// A widget on the last row Tk accepts gets one warning for the rows
+	// above it rather than one each.
+	warnings := newTestApp(t, "ADD|$|Label|$|name|@|a|:|row|@|9999|:|column|@|0").LintLayout()
+	if len(warnings) != 1 || warnings[0].String() != "rows 0-9998 are empty" {
+		t.Errorf("got %v", warnings)
+	}
+}
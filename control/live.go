@@ -0,0 +1,96 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// livePreviewDelay is how long the project has to stay unchanged before
+// the live preview is restarted with the new build.
+const livePreviewDelay = 500 * time.Millisecond
+
+// previewWrapper runs a build as __main__ and keeps the window where the
+// user left it: the geometry of the root window is saved as it changes and
+// restored on the next start. The size is only restored while the build
+// still asks for the size it asked for when it was saved.
+const previewWrapper = `import os, runpy, sys, tkinter
+
+build, saved_path = sys.argv[1], sys.argv[2]
+sys.argv = [build]
+sys.path[0] = os.path.dirname(os.path.abspath(build))
+try:
+    with open(saved_path) as saved_in:
+        saved = saved_in.read().split()
+except OSError:
+    saved = []
+requested = [None]
+set_geometry = tkinter.Wm.wm_geometry
+tk_init = tkinter.Tk.__init__
+
+
+def wm_geometry(self, new_geometry=None):
+    if new_geometry is not None and isinstance(self, tkinter.Tk):
+        requested[0] = new_geometry
+    return set_geometry(self, new_geometry)
+
+
+def restore(root):
+    if len(saved) == 2:
+        size = saved[1].split('+')[0].split('-')[0]
+        if saved[0] == str(requested[0]):
+            set_geometry(root, saved[1])
+        elif len(saved[1]) > len(size):
+            set_geometry(root, saved[1][len(size):])
+    root.bind('<Configure>', lambda event: save(root, event), '+')
+
+
+def save(root, event):
+    if event.widget is root:
+        with open(saved_path, 'w') as saved_out:
+            saved_out.write('%s %s' % (requested[0], set_geometry(root)))
+
+
+def init(self, *args, **kwargs):
+    tk_init(self, *args, **kwargs)
+    self.after_idle(restore, self)
+
+
+tkinter.Tk.__init__ = init
+tkinter.Wm.wm_geometry = tkinter.Wm.geometry = wm_geometry
+runpy.run_path(build, run_name='__main__')
+`
+
+// geometryPath is the file the preview wrapper saves the window geometry
+// to.
+func (app *AppParser) geometryPath() string {
+	return fmt.Sprintf("%s.geometry", app.Project)
+}
+
+// reloadPreview restarts the preview with a new build once the project has
+// stayed unchanged for livePreviewDelay. A build that does not compile
+// leaves the running preview alone.
+func (app *AppParser) reloadPreview() {
+	if len(app.Executable) < 1 || app.buildError != nil || bytes.Equal(app.Build.Bytes(), app.liveBuild) {
+		return
+	}
+	app.liveBuild = append(app.liveBuild[:0], app.Build.Bytes()...)
+	if app.liveTimer != nil {
+		app.liveTimer.Stop()
+	}
+	build, sources := app.goodBuild(), app.goodSources
+	app.liveTimer = time.AfterFunc(livePreviewDelay, func() {
+		app.startPreview(build, sources, true)
+	})
+}
+
+// stopLivePreview cancels a pending restart of the live preview.
+func (app *AppParser) stopLivePreview() {
+	if app.liveTimer != nil {
+		app.liveTimer.Stop()
+	}
+	app.liveTimer, app.liveBuild = nil, nil
+}
@@ -0,0 +1,135 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// metaOptions are the settings of the project's META entry. The window
+// title they go with stays in the TITLE entry.
+var metaOptions = []Option{
+	{Name: "classname", Type: IdentifierOption},
+	{Name: "module", Type: IdentifierOption},
+	{Name: "version", Type: TextOption},
+	{Name: "author", Type: TextOption},
+	{Name: "license", Type: TextOption},
+}
+
+// SetMetadata changes the application metadata given as name|@|value
+// pairs; title sets the window title. An empty value clears a setting.
+func (app *AppParser) SetMetadata(update []string) error {
+	settings := map[string]string{}
+	for _, pair := range update {
+		kv := strings.Split(pair, "|@|")
+		if len(kv) != 2 {
+			return fmt.Errorf("malformed application setting %q", pair)
+		}
+		name, value := kv[0], strings.TrimSpace(kv[1])
+		if err := checkMetadata(name, value); err != nil {
+			return err
+		}
+		settings[name] = value
+	}
+
+	if app.MapBuild["META"] == nil {
+		app.MapBuild["META"] = make(map[string]interface{})
+	}
+	for name, value := range settings {
+		switch {
+		case name == "title":
+			app.MapBuild["TITLE"]["title"] = value
+		case len(value) < 1:
+			delete(app.MapBuild["META"], name)
+		default:
+			app.MapBuild["META"][name] = value
+		}
+	}
+	return nil
+}
+
+func checkMetadata(name, value string) error {
+	if strings.ContainsAny(value, "\\\n") || strings.Contains(value, `"""`) {
+		return &OptionError{name, "may not contain backslashes, newlines or triple quotes"}
+	}
+	if name == "title" {
+		if len(value) < 1 {
+			return &OptionError{name, "the window needs a title"}
+		}
+		return nil
+	}
+	for _, option := range metaOptions {
+		if option.Name != name {
+			continue
+		}
+		if len(value) < 1 {
+			return nil
+		}
+		_, err := option.Normalize(value)
+		if err != nil {
+			return &OptionError{name, err.Error()}
+		}
+		return nil
+	}
+	return &OptionError{name, "not an application setting"}
+}
+
+// metadata returns the application metadata templates are executed with.
+// The class name defaults to the title in CamelCase and the module name to
+// the class name in snake_case.
+func (cont AppController) metadata() map[string]interface{} {
+	meta := map[string]interface{}{}
+	for name, value := range cont.MapBuild["META"] {
+		meta[name] = value
+	}
+	title, _ := cont.MapBuild["TITLE"]["title"].(string)
+	meta["title"] = title
+	if className, _ := meta["classname"].(string); len(className) < 1 {
+		meta["classname"] = camelCase(title)
+	}
+	if module, _ := meta["module"].(string); len(module) < 1 {
+		meta["module"] = snakeCase(meta["classname"].(string))
+	}
+	return meta
+}
+
+// templateProject returns the project as the templates see it, with the
+// metadata complete.
+func (cont AppController) templateProject() map[string]map[string]interface{} {
+	project := make(map[string]map[string]interface{}, len(cont.MapBuild)+1)
+	for key, value := range cont.MapBuild {
+		project[key] = value
+	}
+	project["META"] = cont.metadata()
+	return project
+}
+
+// camelCase turns a window title such as "My App" into a class name such
+// as "MyApp"; titles that are already identifiers are kept.
+func camelCase(title string) string {
+	if identifier.MatchString(title) && !pythonKeywords[title] {
+		return title
+	}
+	var name []rune
+	upper := true
+	for _, char := range title {
+		switch {
+		case char > unicode.MaxASCII:
+			upper = true
+		case unicode.IsLetter(char) || unicode.IsDigit(char) && len(name) > 0:
+			if upper {
+				char = unicode.ToUpper(char)
+			}
+			name, upper = append(name, char), false
+		default:
+			upper = true
+		}
+	}
+	if len(name) < 1 || pythonKeywords[string(name)] {
+		return "App" + string(name)
+	}
+	return string(name)
+}
@@ -0,0 +1,185 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var tracebackFile = regexp.MustCompile(`File "([^"]+)", line (\d+)`)
+
+// stopGrace is how long a preview has to exit after being asked to before
+// it is killed.
+const stopGrace = 2 * time.Second
+
+// tracebackSize is how much of the end of a preview's output is kept to
+// look for a traceback in.
+const tracebackSize = 64 * 1024
+
+// previewProcess is a running preview. done is closed once it has been
+// reaped and its exit status reported.
+type previewProcess struct {
+	cmd     *exec.Cmd
+	done    chan struct{}
+	stopped bool
+}
+
+// previews are the previews started by BUILD or live reloading that are
+// still running. starting is held while a preview is started, and no more
+// are started once closed is set.
+type previews struct {
+	sync.Mutex
+	running  map[*previewProcess]bool
+	starting sync.Mutex
+	closed   bool
+}
+
+// tailBuffer keeps the last tracebackSize bytes written to it.
+type tailBuffer struct {
+	data []byte
+}
+
+func (tail *tailBuffer) Write(data []byte) (int, error) {
+	tail.data = append(tail.data, data...)
+	if len(tail.data) > tracebackSize {
+		tail.data = tail.data[len(tail.data)-tracebackSize:]
+	}
+	return len(data), nil
+}
+
+// consolePath is the file the preview's output goes to, which the designer
+// shows in its console.
+func (app *AppParser) consolePath() string {
+	return fmt.Sprintf("%s.console", app.Project)
+}
+
+// startPreview runs build in the background through previewWrapper, with
+// its output going to the console. With replace, the previews already
+// running are stopped first.
+func (app *AppParser) startPreview(build string, sources SourceMap, replace bool) error {
+	app.previews.starting.Lock()
+	defer app.previews.starting.Unlock()
+	if app.previews.closed {
+		return nil
+	}
+	if replace {
+		app.StopPreviews()
+	}
+	console, err := os.OpenFile(app.consolePath(), os.O_WRONLY|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	output := &tailBuffer{}
+	preview := &previewProcess{
+		cmd:  exec.Command(app.Executable, "-u", "-c", previewWrapper, build, app.geometryPath()),
+		done: make(chan struct{}),
+	}
+	preview.cmd.Stdout = io.MultiWriter(console, output)
+	preview.cmd.Stderr = preview.cmd.Stdout
+	if err := preview.cmd.Start(); err != nil {
+		fmt.Fprintf(console, "visipy: %v\n", err)
+		console.Close()
+		return err
+	}
+
+	app.previews.Lock()
+	if app.previews.running == nil {
+		app.previews.running = make(map[*previewProcess]bool)
+	}
+	app.previews.running[preview] = true
+	app.previews.Unlock()
+	go app.watchPreview(preview, console, output, build, sources)
+	return nil
+}
+
+// StopPreviews stops the running previews, killing those that do not exit
+// within stopGrace, and waits for them to be reaped.
+func (app *AppParser) StopPreviews() {
+	var running []*previewProcess
+	app.previews.Lock()
+	for preview := range app.previews.running {
+		preview.stopped = true
+		running = append(running, preview)
+	}
+	app.previews.Unlock()
+
+	for _, preview := range running {
+		if err := preview.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+			preview.cmd.Process.Kill()
+		}
+		select {
+		case <-preview.done:
+		case <-time.After(stopGrace):
+			preview.cmd.Process.Kill()
+			<-preview.done
+		}
+	}
+}
+
+// closePreviews stops the running previews for good, for when the designer
+// exits.
+func (app *AppParser) closePreviews() {
+	app.stopLivePreview()
+	app.previews.starting.Lock()
+	app.previews.closed = true
+	app.previews.starting.Unlock()
+	app.StopPreviews()
+}
+
+// watchPreview reaps a preview and adds how it exited to the console,
+// followed by where in the project a traceback points to.
+func (app *AppParser) watchPreview(preview *previewProcess, console *os.File, output *tailBuffer, build string, sources SourceMap) {
+	defer close(preview.done)
+	defer console.Close()
+	err := preview.cmd.Wait()
+
+	app.previews.Lock()
+	delete(app.previews.running, preview)
+	stopped := preview.stopped
+	app.previews.Unlock()
+
+	exitErr, exited := err.(*exec.ExitError)
+	switch {
+	case stopped:
+		fmt.Fprintf(console, "\nvisipy: preview stopped\n")
+		return
+	case err == nil:
+		fmt.Fprintf(console, "\nvisipy: preview exited\n")
+	case exited && exitErr.ExitCode() >= 0:
+		fmt.Fprintf(console, "\nvisipy: preview exited with status %d\n", exitErr.ExitCode())
+	default:
+		fmt.Fprintf(console, "\nvisipy: preview crashed: %v\n", err)
+	}
+	if line, found := tracebackLine(output.data, build); found {
+		fmt.Fprintf(console, "visipy: the error is at line %d", line)
+		if source := sources.Lookup(line); len(source) > 0 {
+			fmt.Fprintf(console, ", in %s", source)
+		}
+		fmt.Fprintln(console)
+	}
+}
+
+// tracebackLine finds the innermost line of the build a Python traceback
+// in output goes through.
+func tracebackLine(output []byte, build string) (int, bool) {
+	build, _ = filepath.Abs(build)
+	matches := tracebackFile.FindAllSubmatch(output, -1)
+	for index := len(matches) - 1; index >= 0; index-- {
+		path, _ := filepath.Abs(string(matches[index][1]))
+		if path == build {
+			line, err := strconv.Atoi(string(matches[index][2]))
+			return line, err == nil
+		}
+	}
+	return 0, false
+}
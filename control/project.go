@@ -0,0 +1,199 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Project is a Visipy project built from Go instead of the designer. Its
+// methods check their arguments the way the designer's commands are
+// checked, and Generate writes the same code the designer shows.
+type Project struct {
+	app *AppParser
+}
+
+// Widget is a widget to add to a Project: a built-in type such as "Button"
+// or a registered custom one, a name that is a Python identifier, its grid
+// cell in Parent, a container such as a Frame or empty for the window, and
+// its other options written as in the designer, e.g.
+// {"text": "OK", "width": "10", "sticky": "W+E"}.
+type Widget struct {
+	Type    string
+	Name    string
+	Parent  string
+	Row     int
+	Column  int
+	Options map[string]string
+}
+
+// NewProject returns a project with the designer's defaults: an empty
+// window titled MyApp.
+func NewProject() *Project {
+	app := &AppParser{}
+	app.setIndent()
+	app.initUserApp()
+	return &Project{app}
+}
+
+// OpenProject reads a project saved by Save or by the designer, loading the
+// custom widgets and templates next to it.
+func OpenProject(path string) (*Project, error) {
+	app := &AppParser{}
+	if err := app.LoadProject(path); err != nil {
+		return nil, err
+	}
+	return &Project{app}, nil
+}
+
+// SetTitle sets the window title.
+func (project *Project) SetTitle(title string) error {
+	return project.app.ApplyCommand([]string{"TITLE", title})
+}
+
+// SetColor sets the window's background color, a Tk color name or #rrggbb.
+func (project *Project) SetColor(color string) error {
+	return project.app.ApplyCommand([]string{"APPCOLOR", color})
+}
+
+// SetDimensions sets the window's size in pixels.
+func (project *Project) SetDimensions(width, height int) error {
+	if width < 1 || height < 1 {
+		return fmt.Errorf("dimensions %dx%d: width and height must be positive", width, height)
+	}
+	return project.app.ApplyCommand([]string{"DIMENSIONS", fmt.Sprintf("%dx%d", width, height)})
+}
+
+// SetMenuColor sets the colors of the window menu.
+func (project *Project) SetMenuColor(foreground, background string) error {
+	return project.app.ApplyCommand([]string{"MENUCOLOR", foreground + "|:|" + background})
+}
+
+// SetTheme sets the ttk theme, e.g. clam.
+func (project *Project) SetTheme(theme string) error {
+	return project.app.ApplyCommand([]string{"THEME", theme})
+}
+
+// SetIcon sets the image file used as the window icon.
+func (project *Project) SetIcon(path string) error {
+	return project.app.ApplyCommand([]string{"ICON", path})
+}
+
+// SetStyle changes code style settings, e.g. {"indent": "2"}; see
+// CodeStyle for the settings.
+func (project *Project) SetStyle(settings map[string]string) error {
+	return project.app.SetStyle(pairs(settings))
+}
+
+// SetMetadata changes the application metadata: classname, module,
+// version, author and license. An empty value clears a setting.
+func (project *Project) SetMetadata(settings map[string]string) error {
+	return project.app.SetMetadata(pairs(settings))
+}
+
+// SetTarget sets the oldest tk and python versions the generated app has
+// to run with. An empty value clears a setting.
+func (project *Project) SetTarget(settings map[string]string) error {
+	return project.app.SetTarget(pairs(settings))
+}
+
+// AddWidget adds a new widget to the project.
+func (project *Project) AddWidget(widget Widget) error {
+	if err := project.app.validName(widget.Name); err != nil {
+		return err
+	}
+	update := []string{
+		"name|@|" + widget.Name,
+		"row|@|" + strconv.Itoa(widget.Row),
+		"column|@|" + strconv.Itoa(widget.Column),
+		"parent|@|" + widget.Parent,
+	}
+	for _, pair := range pairs(widget.Options) {
+		switch option := strings.SplitN(pair, "|@|", 2)[0]; option {
+		case "name", "parent", "row", "column", "widget":
+			return fmt.Errorf("%s: set %s with the Widget field", widget.Name, option)
+		}
+		update = append(update, pair)
+	}
+	if err := project.app.SetWidget(widget.Type, update); err != nil {
+		return fmt.Errorf("%s: %v", widget.Name, err)
+	}
+	return nil
+}
+
+// AddMenu adds a window menu, or replaces the items of an existing one.
+// Each item calls quit_ until the generated code is edited.
+func (project *Project) AddMenu(title string, items ...string) error {
+	for _, label := range append([]string{title}, items...) {
+		if len(strings.TrimSpace(label)) < 1 || strings.ContainsAny(label, ",|") {
+			return fmt.Errorf("menu label %q must not be empty or contain commas or |", label)
+		}
+	}
+	if _, err := project.app.widget(title); err == nil || contains(projectSettings, title) {
+		return fmt.Errorf("menu %q: the name is taken", title)
+	}
+	if len(items) < 1 {
+		return fmt.Errorf("menu %q has no items", title)
+	}
+	delete(project.app.MapBuild, title)
+	return project.app.ApplyCommand([]string{"MENU", strings.Join(append([]string{title}, items...), ",")})
+}
+
+// Remove removes a widget or menu.
+func (project *Project) Remove(name string) error {
+	if _, exists := project.app.MapBuild[name]; !exists || contains(projectSettings, name) {
+		return fmt.Errorf("no widget or menu named %q", name)
+	}
+	return project.app.ApplyCommand([]string{"REMOVE", name})
+}
+
+// Lint returns the project's layout and target version warnings.
+func (project *Project) Lint() []LayoutWarning {
+	return project.app.Lint()
+}
+
+// Generate writes the project's Python code to out. The same project
+// always generates the same code.
+func (project *Project) Generate(out io.Writer) error {
+	if err := project.app.generate(); err != nil {
+		return err
+	}
+	return project.app.WriteBuild(out)
+}
+
+// RenderSVG draws the project's grid layout as SVG.
+func (project *Project) RenderSVG(out io.Writer) error {
+	return RenderSVG(project.app.MapBuild, out)
+}
+
+// RenderPNG draws the project's grid layout as PNG.
+func (project *Project) RenderPNG(out io.Writer) error {
+	return RenderPNG(project.app.MapBuild, out)
+}
+
+// Save writes the project to path as JSON, which OpenProject and the
+// designer's Load can read.
+func (project *Project) Save(path string) error {
+	raw, err := json.Marshal(project.app.MapBuild)
+	if err != nil {
+		return err
+	}
+	return project.app.Utils.WriteFile(path, raw)
+}
+
+// pairs turns settings into the name|@|value pairs of designer commands,
+// sorted so that the first bad setting is always the one reported.
+func pairs(settings map[string]string) []string {
+	var update []string
+	for name, value := range settings {
+		update = append(update, name+"|@|"+value)
+	}
+	sort.Strings(update)
+	return update
+}
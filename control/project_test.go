@@ -0,0 +1,195 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// picker is a custom widget type with several handlers, which Generate
+// must write in the same order every time.
+var picker = WidgetSchema{
+	Name:  "Picker",
+	Class: "Picker",
+	Options: []Option{
+		{Name: "onpick", Type: IdentifierOption},
+		{Name: "onclear", Type: IdentifierOption},
+		{Name: "onopen", Type: IdentifierOption},
+		{Name: "onclose", Type: IdentifierOption},
+	},
+	Handlers: map[string]string{
+		"onpick":  "(self, item)",
+		"onclear": "(self)",
+		"onopen":  "(self, event)",
+		"onclose": "(self, event)",
+	},
+}
+
+func newDeterminismProject(t *testing.T) *Project {
+	t.Helper()
+	project := NewProject()
+	project.app.projectWidgets = map[string]WidgetSchema{"Picker": picker}
+	for _, widget := range []Widget{
+		{Type: "Frame", Name: "box"},
+		{Type: "Picker", Name: "pick", Parent: "box", Options: map[string]string{
+			"onpick": "picked", "onclear": "cleared", "onopen": "opened", "onclose": "closed",
+		}},
+		{Type: "Button", Name: "ok", Row: 1, Options: map[string]string{"text": "OK", "command": "ok_clicked"}},
+		{Type: "Button", Name: "cancel", Row: 1, Column: 1, Options: map[string]string{"command": "cancel_clicked"}},
+		{Type: "Entry", Name: "query", Row: 2, Options: map[string]string{"foreground": "steel blue", "font": "Courier 10 bold"}},
+	} {
+		if err := project.AddWidget(widget); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, menu := range [][]string{{"File", "Open", "Quit"}, {"Help", "About"}} {
+		if err := project.AddMenu(menu[0], menu[1:]...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return project
+}
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	var first bytes.Buffer
+	if err := newDeterminismProject(t).Generate(&first); err != nil {
+		t.Fatal(err)
+	}
+	methods := []string{"def picked(self, item)", "def cleared(self)", "def opened(self, event)",
+		"def closed(self, event)", "def ok_clicked(self)", "def cancel_clicked(self)"}
+	last := -1
+	for _, method := range methods {
+		index := strings.Index(first.String(), method)
+		if index < last {
+			t.Errorf("%s is out of order:\n%s", method, first.String())
+		}
+		last = index
+	}
+
+	// Map order changes from run to run, so a few runs catch most of it.
+	for run := 0; run < 20; run++ {
+		var again bytes.Buffer
+		if err := newDeterminismProject(t).Generate(&again); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(again.Bytes(), first.Bytes()) {
+			t.Fatalf("run %d generated different code:\n%s\nfirst:\n%s", run, again.String(), first.String())
+		}
+	}
+
+	dir := t.TempDir()
+	definition, err := json.Marshal(picker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, dir, map[string]string{"widgets/Picker.json": string(definition)})
+	path := filepath.Join(dir, "app.py.project")
+	if err := newDeterminismProject(t).Save(path); err != nil {
+		t.Fatal(err)
+	}
+	opened, err := OpenProject(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reopened bytes.Buffer
+	if err := opened.Generate(&reopened); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(reopened.Bytes(), first.Bytes()) {
+		t.Errorf("the saved project generates different code:\n%s", reopened.String())
+	}
+}
+
+func TestScaleCommandTakesValue(t *testing.T) {
+	project := NewProject()
+	if err := project.AddWidget(Widget{Type: "Scale", Name: "level", Options: map[string]string{"command": "changed"}}); err != nil {
+		t.Fatal(err)
+	}
+	var code bytes.Buffer
+	if err := project.Generate(&code); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(code.String(), "def changed(self, value):") {
+		t.Errorf("Scale handler doesn't take the value:\n%s", code.String())
+	}
+}
+
+// compiles byte-compiles generated code with python3, when there is one.
+func compiles(t *testing.T, code string) {
+	t.Helper()
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("no python3 to compile with")
+	}
+	path := filepath.Join(t.TempDir(), "app.py")
+	if err := os.WriteFile(path, []byte(code), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if output, err := exec.Command(python, "-m", "py_compile", path).CombinedOutput(); err != nil {
+		t.Errorf("code doesn't compile: %v\n%s\n%s", err, output, code)
+	}
+}
+
+func TestAddMenuTitles(t *testing.T) {
+	project := NewProject()
+	for _, menu := range [][]string{{"Help Me", "It's"}, {"It's", "Open"}, {"2nd", "Back\\"}} {
+		if err := project.AddMenu(menu[0], menu[1:]...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var code bytes.Buffer
+	if err := project.Generate(&code); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"help_me_menu = Menu(menu)", "it_s_menu = Menu(menu)", "_2nd_menu = Menu(menu)",
+		`label='It\'s'`, `label='Back\\'`} {
+		if !strings.Contains(code.String(), want) {
+			t.Errorf("code lacks %s:\n%s", want, code.String())
+		}
+	}
+	compiles(t, code.String())
+}
+
+func TestGenerateQuotesText(t *testing.T) {
+	image := filepath.Join(t.TempDir(), "o'brien.gif")
+	if err := os.WriteFile(image, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, quotes := range []string{"single", "double"} {
+		project := NewProject()
+		if err := project.SetStyle(map[string]string{"quotes": quotes}); err != nil {
+			t.Fatal(err)
+		}
+		if err := project.SetIcon(image); err != nil {
+			t.Fatal(err)
+		}
+		for _, widget := range []Widget{
+			{Type: "Label", Name: "note", Options: map[string]string{"text": "It's here"}},
+			{Type: "Entry", Name: "secret", Row: 1, Options: map[string]string{"show": "*"}},
+			{Type: "Image", Name: "logo", Row: 2, Options: map[string]string{"image": image}},
+			{Type: "Button", Name: "plain", Row: 3},
+		} {
+			if err := project.AddWidget(widget); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var code bytes.Buffer
+		if err := project.Generate(&code); err != nil {
+			t.Fatal(err)
+		}
+		if quotes == "double" && !strings.Contains(code.String(), `text="It's here"`) {
+			t.Errorf("%s quotes: text not quoted:\n%s", quotes, code.String())
+		}
+		if strings.Contains(code.String(), "no value") || strings.Contains(code.String(), "<nil>") {
+			t.Errorf("%s quotes: unset options were generated:\n%s", quotes, code.String())
+		}
+		compiles(t, code.String())
+	}
+}
@@ -0,0 +1,184 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// WidgetDirName is the directory, next to a project or in the user's
+// config directory, that custom widget definitions are loaded from.
+const WidgetDirName = "widgets"
+
+var (
+	className = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+	signature = regexp.MustCompile(`^\(self\b.*\)$`)
+)
+
+// LoadWidgetDir registers every *.json widget definition in dir for all
+// projects. A missing directory is not an error.
+func LoadWidgetDir(dir string) error {
+	schemas, err := readWidgetDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, schema := range schemas {
+		RegisterWidget(schema)
+	}
+	return nil
+}
+
+// readWidgetDir reads the *.json widget definitions in dir, each holding
+// one WidgetSchema. Options may give just a name to reuse the definition
+// of a built-in option.
+func readWidgetDir(dir string) ([]WidgetSchema, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	var schemas []WidgetSchema
+	for _, path := range paths {
+		raw, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var schema WidgetSchema
+		if err := json.Unmarshal(raw, &schema); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		if err := completeSchema(&schema); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		schemas = append(schemas, schema)
+	}
+	return schemas, nil
+}
+
+// lookupWidget returns the schema of a widget type, preferring the
+// project's own custom types to the registered ones.
+func (cont AppController) lookupWidget(name string) (WidgetSchema, bool) {
+	if schema, exists := cont.projectWidgets[name]; exists {
+		return schema, true
+	}
+	return LookupWidget(name)
+}
+
+// widgetTypes returns the names of the registered widget types and the
+// project's own, sorted.
+func (cont AppController) widgetTypes() []string {
+	names := WidgetTypes()
+	for name := range cont.projectWidgets {
+		if !contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UserWidgetDir returns the per-user directory of custom widget definitions.
+func UserWidgetDir() (string, error) {
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(config, "visipy", WidgetDirName), nil
+}
+
+// completeSchema fills in shared option definitions and checks that a
+// loaded widget definition can be generated.
+func completeSchema(schema *WidgetSchema) error {
+	if !identifier.MatchString(schema.Name) {
+		return fmt.Errorf("widget name %q is not a valid Python name", schema.Name)
+	}
+	if len(schema.Class) < 1 {
+		schema.Class = schema.Name
+	}
+	if !className.MatchString(schema.Class) {
+		return fmt.Errorf("class %q is not a valid Python name", schema.Class)
+	}
+	if len(schema.Import) > 0 && !strings.HasPrefix(schema.Import, "from ") &&
+		!strings.HasPrefix(schema.Import, "import ") {
+		return fmt.Errorf("import %q must be an import statement", schema.Import)
+	}
+
+	for name, value := range schema.Requires {
+		if !contains(targetNames, name) {
+			return fmt.Errorf("requires %q, use tk or python", name)
+		}
+		if _, err := parseVersion(value); err != nil {
+			return fmt.Errorf("requires %s: %v", name, err)
+		}
+	}
+	if needs, known := ttkRequirements[schema.Class]; known && len(schema.Requires) < 1 &&
+		strings.Contains(schema.Import, "tkinter.ttk") {
+		schema.Requires = needs
+	}
+
+	for index, option := range schema.Options {
+		if len(option.Type) < 1 {
+			shared, exists := options[option.Name]
+			if !exists {
+				return fmt.Errorf("option %q needs a type", option.Name)
+			}
+			schema.Options[index], option = shared, shared
+		}
+		switch option.Type {
+		case IntOption, ColorOption, TextOption, FontOption, IdentifierOption, ValuesOption:
+		case ImageOption:
+			if len(schema.Template) < 1 {
+				return fmt.Errorf("image option %q needs a template to load the image", option.Name)
+			}
+		case EnumOption:
+			if len(option.Enum) < 1 {
+				return fmt.Errorf("enum option %q has no values", option.Name)
+			}
+		default:
+			return fmt.Errorf("option %q has unknown type %q", option.Name, option.Type)
+		}
+	}
+
+	for option, params := range schema.Handlers {
+		if _, exists := schema.Option(option); !exists {
+			return fmt.Errorf("handler %q is not one of the widget's options", option)
+		}
+		if !signature.MatchString(params) {
+			return fmt.Errorf("handler %q signature must look like (self, ...)", option)
+		}
+	}
+
+	if len(schema.Template) > 0 {
+		if _, err := template.New(schema.Name).Funcs(templateFuncs(CodeStyle{Indent: 4})).Parse(schema.Template); err != nil {
+			return fmt.Errorf("template: %v", err)
+		}
+	}
+	return nil
+}
+
+// writeWidgetTypes leaves the registered widget types, with the grid
+// options first, where the designer can list them.
+func (app *AppParser) writeWidgetTypes() error {
+	var types []WidgetSchema
+	for _, name := range app.widgetTypes() {
+		schema, _ := app.lookupWidget(name)
+		all := make([]Option, 0, len(gridOptions)+len(schema.Options))
+		schema.Options = append(append(all, gridOptions...), schema.Options...)
+		schema.Template = ""
+		types = append(types, schema)
+	}
+	raw, err := json.Marshal(types)
+	if err != nil {
+		return err
+	}
+	return app.Utils.WriteFile(fmt.Sprintf("%s.widgets", app.Project), raw)
+}
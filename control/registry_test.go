@@ -0,0 +1,152 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles creates files, given by path relative to dir, in dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for path, text := range files {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+const gaugeProject = `{"g": {"widget": "Gauge", "name": "g", "row": "0", "column": "0", "text": "Level"}}`
+
+func TestProjectWidgetTypes(t *testing.T) {
+	custom, plain := t.TempDir(), t.TempDir()
+	writeFiles(t, custom, map[string]string{
+		"project.json":        gaugeProject,
+		"widgets/Gauge.json":  `{"name": "Gauge", "options": [{"name": "text"}]}`,
+		"templates/quit.tmpl": "\ndef quit_():\n\tprint('bye')\n\texit()\n\n",
+	})
+	writeFiles(t, plain, map[string]string{
+		"project.json": `{"l": {"widget": "Label", "name": "l", "row": "0", "column": "0"}}`,
+	})
+
+	app := &AppParser{}
+	if err := app.LoadProject(filepath.Join(custom, "project.json")); err != nil {
+		t.Fatal(err)
+	}
+	code := app.Build.String()
+	for _, want := range []string{"self.g = Gauge(", "text='Level'", "print('bye')"} {
+		if !strings.Contains(code, want) {
+			t.Errorf("custom project code lacks %q:\n%s", want, code)
+		}
+	}
+	if _, leaked := LookupWidget("Gauge"); leaked {
+		t.Error("the project's widget type was registered for every project")
+	}
+	if other := newTestApp(t); other.render(&other.Build, "quit", nil) != nil ||
+		strings.Contains(other.Build.String(), "bye") {
+		t.Error("the project's template override applies to other projects")
+	}
+
+	if err := app.LoadProject(filepath.Join(plain, "project.json")); err != nil {
+		t.Fatal(err)
+	}
+	if _, kept := app.lookupWidget("Gauge"); kept || strings.Contains(app.Build.String(), "bye") {
+		t.Error("the previous project's widget types or templates were kept")
+	}
+	if err := app.LoadProject(filepath.Join(custom, "project.json")); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.ApplyCommand([]string{"RESET"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, kept := app.lookupWidget("Gauge"); kept {
+		t.Error("RESET kept the project's widget types")
+	}
+}
+
+func TestProjectWidgetTypeErrors(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		files map[string]string
+		err   string
+	}{
+		{"bad definition", map[string]string{
+			"widgets/Gauge.json": `{"name": "Gauge", "options": [{"name": "level"}]}`,
+		}, `Gauge.json: option "level" needs a type`},
+		{"template doesn't parse", map[string]string{
+			"widgets/Gauge.json": `{"name": "Gauge", "options": [], "template": "{{.name"}`,
+		}, "Gauge.json: template: "},
+		{"template doesn't run", map[string]string{
+			"widgets/Gauge.json": `{"name": "Gauge", "options": [{"name": "text"}], "template": "{{template \"frame\"}}"}`,
+		}, "Gauge widget g: "},
+		{"override doesn't parse", map[string]string{
+			"widgets/Gauge.json":  `{"name": "Gauge", "options": [{"name": "text"}]}`,
+			"templates/quit.tmpl": "{{if}}",
+		}, "quit.tmpl: "},
+		{"override doesn't run", map[string]string{
+			"widgets/Gauge.json":  `{"name": "Gauge", "options": [{"name": "text"}]}`,
+			"templates/quit.tmpl": `{{template "frame"}}`,
+		}, "template quit: "},
+	} {
+		dir := t.TempDir()
+		test.files["project.json"] = gaugeProject
+		writeFiles(t, dir, test.files)
+		err := (&AppParser{}).LoadProject(filepath.Join(dir, "project.json"))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: error %v, want %q", test.name, err, test.err)
+		}
+	}
+
+	// Templates set up from Go skip the checks of a widget directory.
+	app := newTestApp(t)
+	app.projectWidgets = map[string]WidgetSchema{"Gauge": {Name: "Gauge", Class: "Gauge", Template: "{{.name"}}
+	if err := app.ApplyCommand([]string{"ADD", "Gauge", "name|@|g|:|row|@|0|:|column|@|0"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.generate(); err == nil || !strings.Contains(err.Error(), "Gauge widget g: ") {
+		t.Errorf("generate: error %v, want the widget named", err)
+	}
+}
+
+func TestProjectWidgetTypesUndo(t *testing.T) {
+	custom, plain := t.TempDir(), t.TempDir()
+	writeFiles(t, custom, map[string]string{
+		"project.json":       gaugeProject,
+		"widgets/Gauge.json": `{"name": "Gauge", "options": [{"name": "text"}]}`,
+	})
+	writeFiles(t, plain, map[string]string{
+		"project.json": `{"l": {"widget": "Label", "name": "l", "row": "1", "column": "0"}}`,
+	})
+
+	app := newTestApp(t, "LOADUSERPROJ|$|"+filepath.Join(custom, "project.json"), "RESET", "UNDO")
+	if _, known := app.lookupWidget("Gauge"); !known {
+		t.Fatal("UNDO of RESET lost the project's widget types")
+	}
+	if err := app.generate(); err != nil || !strings.Contains(app.Build.String(), "text='Level'") {
+		t.Errorf("generate: error %v, code:\n%s", err, app.Build.String())
+	}
+
+	// A second project adds its widgets and types to the open one.
+	if err := app.ApplyCommand([]string{"LOADUSERPROJ", filepath.Join(plain, "project.json")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, known := app.lookupWidget("Gauge"); !known {
+		t.Error("loading a plain project dropped the open project's widget types")
+	}
+
+	app.projectWidgets = nil
+	if err := app.generate(); err == nil || !strings.Contains(err.Error(), `widget g: unknown widget type "Gauge"`) {
+		t.Errorf("generate: error %v, want the unknown type named", err)
+	}
+	if strings.Contains(app.Build.String(), "Gauge(") {
+		t.Errorf("generated a widget of an unknown type:\n%s", app.Build.String())
+	}
+}
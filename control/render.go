@@ -0,0 +1,344 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// The window decorations drawn above the grid.
+const (
+	titleBarHeight = 24
+	menuBarHeight  = 22
+)
+
+// maxRenderPixels bounds the size of a PNG, which is drawn in memory at
+// four bytes a pixel.
+const maxRenderPixels = 1 << 25
+
+type rgb [3]uint8
+
+func (c rgb) hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", c[0], c[1], c[2])
+}
+
+func (c rgb) color() color.RGBA {
+	return color.RGBA{c[0], c[1], c[2], 0xff}
+}
+
+var (
+	renderBlack    = rgb{0, 0, 0}
+	renderWhite    = rgb{0xff, 0xff, 0xff}
+	renderGray     = rgb{0xd9, 0xd9, 0xd9}
+	renderDark     = rgb{0x3c, 0x3c, 0x3c}
+	renderBorder   = rgb{0x7f, 0x7f, 0x7f}
+	renderHint     = rgb{0xa0, 0xa0, 0xa0}
+	renderOverflow = rgb{0x80, 0x80, 0x80}
+	renderWarning  = rgb{0xe0, 0x30, 0x30}
+)
+
+// sceneBox is a rectangle, filled and outlined when the colors are set.
+type sceneBox struct {
+	x, y, width, height int
+	fill, stroke        *rgb
+	dashed              bool
+}
+
+// sceneText is a line of text, aligned (l)eft, (c)entered or (r)ight of x
+// and centered on y.
+type sceneText struct {
+	x, y       int
+	text       string
+	ink        rgb
+	lineHeight int
+	align      byte
+}
+
+// scene is what a layout preview draws, shared by the SVG and PNG output.
+type scene struct {
+	width, height int
+	boxes         []sceneBox
+	texts         []sceneText
+}
+
+func (s *scene) box(x, y, width, height int, fill, stroke *rgb, dashed bool) {
+	s.boxes = append(s.boxes, sceneBox{x, y, width, height, fill, stroke, dashed})
+}
+
+func (s *scene) text(x, y int, text string, ink rgb, lineHeight int, align byte) {
+	if len(text) > 0 {
+		s.texts = append(s.texts, sceneText{x, y, text, ink, lineHeight, align})
+	}
+}
+
+// newScene lays out a project the way NewGridLayout estimates it: the
+// window with its title and menu bars, the grid's rows and columns, and
+// each widget placed in its cells by its sticky option. Whatever the grid
+// needs beyond the window's dimensions is drawn on gray.
+func newScene(project map[string]map[string]interface{}) scene {
+	grid := NewGridLayout(project)
+	gridWidth, gridHeight := grid.Width(), grid.Height()
+	windowWidth, windowHeight := grid.WindowWidth, grid.WindowHeight
+	if windowWidth < 1 || windowHeight < 1 {
+		windowWidth, windowHeight = maxInt(gridWidth, 200), maxInt(gridHeight, 100)
+	}
+
+	var menus []string
+	for _, key := range (AppController{MapBuild: project}).sortedKeys() {
+		if _, isMenu := project[key]["submenu0"]; isMenu {
+			menus = append(menus, key)
+		}
+	}
+	top := titleBarHeight
+	if len(menus) > 0 {
+		top += menuBarHeight
+	}
+
+	s := scene{width: maxInt(windowWidth, gridWidth), height: top + maxInt(windowHeight, gridHeight)}
+	charWidth, lineHeight := fontMetrics(nil)
+	s.box(0, 0, s.width, s.height, &renderOverflow, nil, false)
+	s.box(0, 0, windowWidth, titleBarHeight, &renderDark, nil, false)
+	title, _ := project["TITLE"]["title"].(string)
+	s.text(windowWidth/2, titleBarHeight/2, title, renderWhite, lineHeight, 'c')
+	if len(menus) > 0 {
+		background := optionColor(project["MENUCOLOR"], "background", rgb{0x66, 0x66, 0x66})
+		foreground := optionColor(project["MENUCOLOR"], "foreground", renderGray)
+		s.box(0, titleBarHeight, windowWidth, menuBarHeight, &background, nil, false)
+		x := 8
+		for _, menu := range menus {
+			s.text(x, titleBarHeight+menuBarHeight/2, menu, foreground, lineHeight, 'l')
+			x += utf8.RuneCountInString(menu)*charWidth + 16
+		}
+	}
+	background := optionColor(project["APPCOLOR"], "appcolor", renderGray)
+	s.box(0, top, windowWidth, windowHeight, &background, nil, false)
+
+	// The grid's lines, in a color that shows on the window's.
+	lines := renderHint
+	if int(background[0])+int(background[1])+int(background[2]) > 3*0xa0 {
+		lines = renderBorder
+	}
+	for column := 0; column <= len(grid.ColumnWidths); column++ {
+		s.box(grid.ColumnOffset(column), top, 0, gridHeight, nil, &lines, true)
+	}
+	for row := 0; row <= len(grid.RowHeights); row++ {
+		s.box(0, top+grid.RowOffset(row), gridWidth, 0, nil, &lines, true)
+	}
+
+	s.grid(project, grid, 0, top)
+	if gridWidth > windowWidth || gridHeight > windowHeight {
+		s.box(0, top, windowWidth, windowHeight, nil, &renderWarning, true)
+	}
+	return s
+}
+
+// grid draws the widgets of a grid that starts at originX, originY.
+func (s *scene) grid(project map[string]map[string]interface{}, grid GridLayout, originX, originY int) {
+	for _, cell := range grid.Placements {
+		s.widget(project, grid, cell, originX, originY)
+	}
+}
+
+// widget draws a widget in its grid cells, and the widgets inside it when
+// it's a container.
+func (s *scene) widget(project map[string]map[string]interface{}, grid GridLayout, cell Placement, originX, originY int) {
+	widget := project[cell.Name]
+	cellX, cellY := originX+grid.ColumnOffset(cell.Column), originY+grid.RowOffset(cell.Row)
+	cellWidth := grid.ColumnOffset(cell.Column+cell.ColumnSpan) - grid.ColumnOffset(cell.Column)
+	cellHeight := grid.RowOffset(cell.Row+cell.RowSpan) - grid.RowOffset(cell.Row)
+	padx, pady := sizeAttr(widget, "padx"), sizeAttr(widget, "pady")
+	sticky, _ := widget["sticky"].(string)
+	x, width := stick(cellX+padx, cellWidth-2*padx, cell.Width-2*padx,
+		strings.Contains(sticky, "W"), strings.Contains(sticky, "E"))
+	y, height := stick(cellY+pady, cellHeight-2*pady, cell.Height-2*pady,
+		strings.Contains(sticky, "N"), strings.Contains(sticky, "S"))
+
+	fill := renderGray
+	switch cell.Widget {
+	case "Entry", "Listbox", "Spinbox", "Text":
+		fill = renderWhite
+	}
+	fill = optionColor(widget, "background", fill)
+	ink := optionColor(widget, "foreground", renderBlack)
+	s.box(x, y, width, height, &fill, &renderBorder, false)
+
+	charWidth, lineHeight := fontMetrics(widget)
+	text, _ := widget["text"].(string)
+	if inner := containerLayout(project, cell.Name); len(inner.Placements) > 0 {
+		border := sizeAttr(widget, "borderwidth")
+		labelHeight := 0
+		if cell.Widget == "LabelFrame" && len(text) > 0 {
+			labelHeight = lineHeight
+			s.text(x+8, y+lineHeight/2, text, ink, lineHeight, 'l')
+		}
+		s.grid(project, inner, x+border, y+border+labelHeight)
+		return
+	}
+	left, right := x+4, x+width-4
+	switch cell.Widget {
+	case "Checkbutton", "Radiobutton":
+		indicator := renderWhite
+		s.box(left, y+height/2-5, 10, 10, &indicator, &renderBorder, false)
+		left += 16
+		anchor, _ := widget["anchor"].(string)
+		if !strings.Contains(anchor, "E") && anchor != "CENTER" {
+			widget = map[string]interface{}{"anchor": "W"}
+		}
+	case "Scale":
+		trough := optionColor(widget, "troughcolor", rgb{0xc3, 0xc3, 0xc3})
+		if widget["orient"] == "HORIZONTAL" {
+			s.box(left, y+height/2-4, width-8, 8, &trough, &renderBorder, false)
+			s.box(left, y+height/2-4, minInt(30, width-8), 8, &fill, &renderBorder, false)
+		} else {
+			s.box(x+width/2-4, y+4, 8, height-8, &trough, &renderBorder, false)
+			s.box(x+width/2-4, y+4, 8, minInt(30, height-8), &fill, &renderBorder, false)
+		}
+		return
+	case "Image":
+		path, _ := widget["image"].(string)
+		text, ink = filepath.Base(path), renderHint
+	}
+	if len(text) < 1 && cell.Widget != "Image" {
+		text, ink = cell.Name, renderHint
+		widget = map[string]interface{}{"anchor": "W"}
+	}
+
+	if runes, limit := []rune(text), (right-left+6)/charWidth; len(runes) > limit {
+		text = string(runes[:maxInt(limit, 0)])
+	}
+	anchor, _ := widget["anchor"].(string)
+	switch {
+	case cell.Widget == "Entry" || cell.Widget == "Spinbox" || anchor == "W" || anchor == "NW" || anchor == "SW":
+		s.text(left, y+height/2, text, ink, lineHeight, 'l')
+	case anchor == "E" || anchor == "NE" || anchor == "SE":
+		s.text(right, y+height/2, text, ink, lineHeight, 'r')
+	default:
+		s.text((left+right)/2, y+height/2, text, ink, lineHeight, 'c')
+	}
+}
+
+// stick places a widget of size along a cell that starts at start and is
+// space long: stretched between both sides, against one, or centered.
+func stick(start, space, size int, low, high bool) (int, int) {
+	switch {
+	case low && high:
+		return start, space
+	case low:
+		return start, size
+	case high:
+		return start + space - size, size
+	}
+	return start + (space-size)/2, size
+}
+
+// optionColor returns the color of a widget option or project setting, or
+// fallback when it is unset or not a color Tk knows.
+func optionColor(options map[string]interface{}, option string, fallback rgb) rgb {
+	value, _ := options[option].(string)
+	if color, known := ColorRGB(value); known {
+		return color
+	}
+	return fallback
+}
+
+// RenderSVG draws a project's grid layout as an SVG image: the window,
+// the grid, and each widget's approximate size, colors and text.
+func RenderSVG(project map[string]map[string]interface{}, out io.Writer) error {
+	s := newScene(project)
+	w := bufio.NewWriter(out)
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		s.width, s.height, s.width, s.height)
+	for _, box := range s.boxes {
+		fill, stroke := "none", "none"
+		if box.fill != nil {
+			fill = box.fill.hex()
+		}
+		if box.stroke != nil {
+			stroke = box.stroke.hex()
+		}
+		dash := ""
+		if box.dashed {
+			dash = ` stroke-dasharray="4 3"`
+		}
+		if box.width == 0 || box.height == 0 {
+			fmt.Fprintf(w, `<line x1="%d.5" y1="%d.5" x2="%d.5" y2="%d.5" stroke="%s"%s/>`+"\n",
+				box.x, box.y, box.x+box.width, box.y+box.height, stroke, dash)
+			continue
+		}
+		fmt.Fprintf(w, `<rect x="%d.5" y="%d.5" width="%d" height="%d" fill="%s" stroke="%s"%s/>`+"\n",
+			box.x, box.y, box.width-1, box.height-1, fill, stroke, dash)
+	}
+	for _, text := range s.texts {
+		anchor := map[byte]string{'l': "start", 'c': "middle", 'r': "end"}[text.align]
+		fmt.Fprintf(w, `<text x="%d" y="%d" fill="%s" font-family="sans-serif" font-size="%d" text-anchor="%s" dominant-baseline="central">%s</text>`+"\n",
+			text.x, text.y, text.ink.hex(), (text.lineHeight+1)*2/3, anchor, xmlEscape(text.text))
+	}
+	fmt.Fprintln(w, "</svg>")
+	return w.Flush()
+}
+
+// RenderPNG draws the same picture as RenderSVG as a PNG image, with the
+// text in a small bitmap font.
+func RenderPNG(project map[string]map[string]interface{}, out io.Writer) error {
+	s := newScene(project)
+	if s.width*s.height > maxRenderPixels {
+		return fmt.Errorf("a %dx%d image is too large to render as PNG, the limit is %d pixels",
+			s.width, s.height, maxRenderPixels)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, s.width, s.height))
+	for _, box := range s.boxes {
+		if box.fill != nil {
+			bounds := image.Rect(box.x, box.y, box.x+box.width, box.y+box.height)
+			draw.Draw(img, bounds, &image.Uniform{box.fill.color()}, image.Point{}, draw.Src)
+		}
+		if box.stroke != nil {
+			outline(img, box)
+		}
+	}
+	for _, text := range s.texts {
+		scale := maxInt(1, text.lineHeight/13)
+		x, width := text.x, textWidth(text.text, scale)
+		switch text.align {
+		case 'c':
+			x -= width / 2
+		case 'r':
+			x -= width
+		}
+		drawText(img, x, text.y-glyphHeight*scale/2, text.text, scale, text.ink.color())
+	}
+	return png.Encode(out, img)
+}
+
+// outline draws the edges of a box one pixel wide, dashed 4 on and 3 off
+// like the SVG.
+func outline(img *image.RGBA, box sceneBox) {
+	ink := box.stroke.color()
+	dot := func(x, y, along int) {
+		if !box.dashed || along%7 < 4 {
+			img.Set(x, y, ink)
+		}
+	}
+	right, bottom := box.x+maxInt(box.width-1, 0), box.y+maxInt(box.height-1, 0)
+	for x := box.x; x <= right; x++ {
+		dot(x, box.y, x-box.x)
+		dot(x, bottom, x-box.x)
+	}
+	for y := box.y; y <= bottom; y++ {
+		dot(box.x, y, y-box.y)
+		dot(right, y, y-box.y)
+	}
+}
+
+func xmlEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(text)
+}
@@ -0,0 +1,104 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestRender(t *testing.T) {
+	for _, test := range []struct {
+		name          string
+		commands      []string
+		width, height int
+		svg           []string // in the SVG
+	}{
+		{"empty window", nil, 300, 424, []string{">MyApp</text>"}},
+		{"menu bar", []string{"MENU|$|File,Quit"}, 300, 446, []string{">File</text>"}},
+		{"grid past the window", []string{
+			"DIMENSIONS|$|40x20",
+			"ADD|$|Label|$|name|@|a|:|row|@|0|:|column|@|0|:|text|@|Name",
+			"ADD|$|Label|$|name|@|b|:|row|@|1|:|column|@|0|:|text|@|Name",
+		}, 40, 70, []string{">Name</text>"}},
+		{"pixel fonts", []string{
+			"ADD|$|Label|$|name|@|tiny|:|row|@|0|:|column|@|0|:|text|@|Tiny|:|font|@|Courier -1",
+			"ADD|$|Button|$|name|@|big|:|row|@|1|:|column|@|0|:|text|@|Big|:|font|@|Courier -14",
+			"ADD|$|Entry|$|name|@|zero|:|row|@|2|:|column|@|0|:|font|@|Courier 0",
+		}, 300, 424, []string{">Tiny</text>", ">Big</text>"}},
+		{"text cut by character", []string{
+			"ADD|$|Label|$|name|@|a|:|row|@|0|:|column|@|0|:|text|@|ééééé|:|width|@|2",
+		}, 300, 424, []string{">éé</text>"}},
+		{"unnamed widgets show their name", []string{
+			"ADD|$|Button|$|name|@|ok|:|row|@|0|:|column|@|0",
+		}, 300, 424, []string{">ok</text>"}},
+	} {
+		project := newTestApp(t, test.commands...).MapBuild
+		var svg, image bytes.Buffer
+		if err := RenderSVG(project, &svg); err != nil {
+			t.Errorf("%s: SVG: %v", test.name, err)
+			continue
+		}
+		if !utf8.Valid(svg.Bytes()) {
+			t.Errorf("%s: SVG is not valid UTF-8", test.name)
+		}
+		size := fmt.Sprintf(`width="%d" height="%d"`, test.width, test.height)
+		for _, want := range append([]string{size}, test.svg...) {
+			if !strings.Contains(svg.String(), want) {
+				t.Errorf("%s: SVG lacks %s:\n%s", test.name, want, svg.String())
+			}
+		}
+
+		if err := RenderPNG(project, &image); err != nil {
+			t.Errorf("%s: PNG: %v", test.name, err)
+			continue
+		}
+		config, err := png.DecodeConfig(&image)
+		if err != nil || config.Width != test.width || config.Height != test.height {
+			t.Errorf("%s: PNG %dx%d, %v; want %dx%d", test.name, config.Width, config.Height, err, test.width, test.height)
+		}
+	}
+}
+
+func TestRenderHugeFrame(t *testing.T) {
+	project := newTestApp(t,
+		"ADD|$|Frame|$|name|@|box|:|row|@|0|:|column|@|0|:|width|@|200000|:|height|@|200000",
+		"ADD|$|Label|$|name|@|a|:|row|@|1|:|column|@|0|:|width|@|9223372036854775807",
+	).MapBuild
+	grid := NewGridLayout(project)
+	if grid.Width() != maxSize || grid.Height() != maxSize+23 {
+		t.Errorf("grid %dx%d, want the frame cut to %d", grid.Width(), grid.Height(), maxSize)
+	}
+	var svg, image bytes.Buffer
+	if err := RenderSVG(project, &svg); err != nil {
+		t.Error("SVG:", err)
+	}
+	if err := RenderPNG(project, &image); err == nil || !strings.Contains(err.Error(), "too large to render") {
+		t.Errorf("PNG: error %v, want the size refused", err)
+	}
+}
+
+func TestFontMetrics(t *testing.T) {
+	for _, test := range []struct {
+		font                  string
+		charWidth, lineHeight int
+	}{
+		{"", 8, 17},
+		{"Courier 12", 10, 23},
+		{"Courier -16", 10, 23},
+		{"Courier -1", 1, 1},
+		{"Courier 1", 1, 1},
+		{"Courier 0", 8, 17},
+		{"not a font {", 8, 17},
+	} {
+		charWidth, lineHeight := fontMetrics(map[string]interface{}{"font": test.font})
+		if charWidth != test.charWidth || lineHeight != test.lineHeight {
+			t.Errorf("%q: %d, %d; want %d, %d", test.font, charWidth, lineHeight, test.charWidth, test.lineHeight)
+		}
+	}
+}
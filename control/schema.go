@@ -0,0 +1,313 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OptionType is the kind of value a widget option accepts.
+type OptionType string
+
+// Option types understood by the validator.
+const (
+	IntOption        OptionType = "int"
+	ColorOption      OptionType = "color"
+	EnumOption       OptionType = "enum"
+	TextOption       OptionType = "text"
+	FontOption       OptionType = "font"
+	IdentifierOption OptionType = "identifier"
+	ImageOption      OptionType = "image"
+	ValuesOption     OptionType = "values"
+)
+
+// Option describes a widget option and the values it accepts.
+type Option struct {
+	Name string     `json:"name"`
+	Type OptionType `json:"type"`
+	Enum []string   `json:"enum,omitempty"`
+}
+
+// WidgetSchema describes a widget type: the Python class it's built from
+// and the options it supports besides the grid options. Custom widget
+// types may also give the import their class needs, their own code
+// template, the signatures of the handler methods their options name and
+// the oldest tk and python versions they run with. Widgets of a container
+// type, such as Frame, can be the parent of other widgets, which are then
+// placed on the container's own grid.
+type WidgetSchema struct {
+	Name      string            `json:"name"`
+	Class     string            `json:"class"`
+	Import    string            `json:"import,omitempty"`
+	Options   []Option          `json:"options"`
+	Template  string            `json:"template,omitempty"`
+	Handlers  map[string]string `json:"handlers,omitempty"`
+	Requires  map[string]string `json:"requires,omitempty"`
+	Container bool              `json:"container,omitempty"`
+
+	builtin bool // generated with the shared widget template
+}
+
+var (
+	reliefs  = []string{"FLAT", "RAISED", "GROOVE", "SUNKEN", "RIDGE"}
+	anchors  = []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW", "CENTER"}
+	stickies = []string{
+		"E", "W", "N", "S", "E+W", "N+S",
+		"N+W", "S+W", "S+E", "N+E", "W+E+N+S",
+	}
+)
+
+// gridOptions are accepted by every widget since they are passed to grid().
+var gridOptions = []Option{
+	{Name: "row", Type: IntOption},
+	{Name: "column", Type: IntOption},
+	{Name: "rowspan", Type: IntOption},
+	{Name: "columnspan", Type: IntOption},
+	{Name: "padx", Type: IntOption},
+	{Name: "pady", Type: IntOption},
+	{Name: "sticky", Type: EnumOption, Enum: stickies},
+}
+
+// options holds the definitions shared by the built-in widget schemas.
+var options = map[string]Option{}
+
+func init() {
+	for _, option := range []Option{
+		{Name: "activebackground", Type: ColorOption},
+		{Name: "activeforeground", Type: ColorOption},
+		{Name: "activestyle", Type: EnumOption, Enum: []string{"DOTBOX", "NONE", "UNDERLINE"}},
+		{Name: "anchor", Type: EnumOption, Enum: anchors},
+		{Name: "background", Type: ColorOption},
+		{Name: "borderwidth", Type: IntOption},
+		{Name: "command", Type: IdentifierOption},
+		{Name: "font", Type: FontOption},
+		{Name: "foreground", Type: ColorOption},
+		{Name: "from", Type: IntOption},
+		{Name: "height", Type: IntOption},
+		{Name: "highlightcolor", Type: ColorOption},
+		{Name: "highlightthickness", Type: IntOption},
+		{Name: "image", Type: ImageOption},
+		{Name: "indicatoron", Type: EnumOption, Enum: []string{"True", "False"}},
+		{Name: "insertbackground", Type: ColorOption},
+		{Name: "insertofftime", Type: IntOption},
+		{Name: "insertontime", Type: IntOption},
+		{Name: "justify", Type: EnumOption, Enum: []string{"LEFT", "CENTER", "RIGHT"}},
+		{Name: "length", Type: IntOption},
+		{Name: "orient", Type: EnumOption, Enum: []string{"HORIZONTAL", "VERTICAL"}},
+		{Name: "relief", Type: EnumOption, Enum: reliefs},
+		{Name: "selectbackground", Type: ColorOption},
+		{Name: "selectborderwidth", Type: IntOption},
+		{Name: "selectcolor", Type: ColorOption},
+		{Name: "selectforeground", Type: ColorOption},
+		{Name: "selectmode", Type: EnumOption, Enum: []string{"BROWSE", "SINGLE", "MULTIPLE", "EXTENDED"}},
+		{Name: "show", Type: EnumOption, Enum: []string{"*"}},
+		{Name: "sliderlength", Type: IntOption},
+		{Name: "sliderrelief", Type: EnumOption, Enum: reliefs},
+		{Name: "text", Type: TextOption},
+		{Name: "tickinterval", Type: IntOption},
+		{Name: "to", Type: IntOption},
+		{Name: "troughcolor", Type: ColorOption},
+		{Name: "values", Type: ValuesOption},
+		{Name: "width", Type: IntOption},
+		{Name: "wrap", Type: EnumOption, Enum: []string{"CHAR", "WORD"}},
+	} {
+		options[option.Name] = option
+	}
+
+	for _, widget := range []struct {
+		name, class string
+		options     []string
+	}{
+		{"Button", "Button", []string{
+			"width", "height", "borderwidth", "highlightthickness", "foreground",
+			"background", "font", "text", "activeforeground", "activebackground",
+			"highlightcolor", "anchor", "command"}},
+		{"Checkbutton", "Checkbutton", []string{
+			"width", "height", "borderwidth", "highlightthickness", "foreground",
+			"background", "font", "text", "activeforeground", "activebackground",
+			"highlightcolor", "indicatoron", "selectcolor", "command"}},
+		{"Entry", "Entry", []string{
+			"width", "borderwidth", "insertontime", "insertofftime", "foreground",
+			"background", "font", "show", "highlightcolor", "insertbackground",
+			"selectforeground", "selectbackground", "justify"}},
+		{"Frame", "Frame", []string{
+			"width", "height", "borderwidth", "highlightthickness", "background",
+			"highlightcolor", "relief"}},
+		{"Image", "Label", []string{"borderwidth", "image", "background"}},
+		{"Label", "Label", []string{
+			"width", "height", "borderwidth", "highlightthickness", "foreground",
+			"background", "font", "text", "activeforeground", "activebackground",
+			"highlightcolor", "anchor"}},
+		{"LabelFrame", "LabelFrame", []string{
+			"width", "height", "borderwidth", "highlightthickness", "foreground",
+			"background", "font", "text", "highlightcolor", "relief"}},
+		{"Listbox", "Listbox", []string{
+			"width", "height", "selectborderwidth", "borderwidth",
+			"highlightthickness", "foreground", "background", "selectmode", "font",
+			"activestyle", "highlightcolor", "selectforeground", "selectbackground",
+			"justify"}},
+		{"Radiobutton", "Radiobutton", []string{
+			"width", "height", "borderwidth", "highlightthickness", "foreground",
+			"background", "font", "text", "activeforeground", "activebackground",
+			"highlightcolor", "indicatoron", "selectcolor", "command"}},
+		{"Scale", "Scale", []string{
+			"width", "length", "from", "to", "tickinterval", "sliderlength",
+			"borderwidth", "foreground", "background", "troughcolor",
+			"activebackground", "highlightcolor", "orient", "sliderrelief",
+			"relief", "command"}},
+		{"Spinbox", "Spinbox", []string{
+			"width", "borderwidth", "insertontime", "insertofftime",
+			"highlightthickness", "foreground", "background", "font",
+			"activebackground", "highlightcolor", "selectforeground",
+			"selectbackground", "values", "command"}},
+		{"Text", "Text", []string{
+			"width", "height", "borderwidth", "insertontime", "insertofftime",
+			"foreground", "background", "font", "highlightcolor",
+			"insertbackground", "selectforeground", "selectbackground", "wrap",
+			"relief"}},
+	} {
+		schema := WidgetSchema{Name: widget.name, Class: widget.class, builtin: true}
+		schema.Container = widget.name == "Frame" || widget.name == "LabelFrame"
+		if widget.name == "Scale" {
+			// Tk passes a Scale's command the new value.
+			schema.Handlers = map[string]string{"command": "(self, value)"}
+		}
+		for _, name := range widget.options {
+			schema.Options = append(schema.Options, options[name])
+		}
+		RegisterWidget(schema)
+	}
+}
+
+var widgetRegistry = map[string]WidgetSchema{}
+
+// RegisterWidget adds or replaces a widget type in the registry.
+func RegisterWidget(schema WidgetSchema) {
+	widgetRegistry[schema.Name] = schema
+}
+
+// LookupWidget returns the schema of a registered widget type.
+func LookupWidget(name string) (WidgetSchema, bool) {
+	schema, exists := widgetRegistry[name]
+	return schema, exists
+}
+
+// WidgetTypes returns the names of all registered widget types, sorted.
+func WidgetTypes() []string {
+	var names []string
+	for name := range widgetRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Option returns the definition of an option the widget type supports,
+// including the grid options.
+func (schema WidgetSchema) Option(name string) (Option, bool) {
+	for _, option := range schema.AllOptions() {
+		if option.Name == name {
+			return option, true
+		}
+	}
+	return Option{}, false
+}
+
+// HandlerSignatures maps each option naming a handler method to the
+// method's parameter list; built-in widgets only have command(self), or
+// command(self, value) for a Scale.
+func (schema WidgetSchema) HandlerSignatures() map[string]string {
+	if schema.Handlers != nil {
+		return schema.Handlers
+	}
+	return map[string]string{"command": "(self)"}
+}
+
+// AllOptions returns the widget's own options followed by the grid options.
+func (schema WidgetSchema) AllOptions() []Option {
+	all := make([]Option, 0, len(schema.Options)+len(gridOptions))
+	return append(append(all, schema.Options...), gridOptions...)
+}
+
+// Validate checks a widget's attributes against the schema and replaces
+// each value with its canonical form. The widget, name and parent, which
+// the project checks, and empty or -1 values are skipped, as they are
+// never generated.
+func (schema WidgetSchema) Validate(attrs map[string]interface{}) error {
+	for _, required := range []string{"row", "column"} {
+		if _, isSet := intAttr(attrs, required); !isSet {
+			return &OptionError{required, "attribute is required"}
+		}
+	}
+	for attr, value := range attrs {
+		if attr == "widget" || attr == "name" || attr == "parent" || isUnset(value) {
+			continue
+		}
+		option, supported := schema.Option(attr)
+		if !supported {
+			return &OptionError{attr, fmt.Sprintf("not supported by %s", schema.Name)}
+		}
+		normalized, err := option.Normalize(fmt.Sprint(value))
+		if err != nil {
+			return &OptionError{attr, err.Error()}
+		}
+		attrs[attr] = normalized
+	}
+	for _, attr := range []string{"row", "column"} {
+		cell, _ := intAttr(attrs, attr)
+		span, isSet := intAttr(attrs, attr+"span")
+		if !isSet {
+			span = 1
+		}
+		if err := checkCell(attr, cell, span); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OptionError reports a widget option the schema refused.
+type OptionError struct {
+	Option  string
+	Message string
+}
+
+func (err *OptionError) Error() string {
+	return fmt.Sprintf("%s: %s", err.Option, err.Message)
+}
+
+// Normalize validates a single value for the option and returns the form
+// it is stored in.
+func (option Option) Normalize(value string) (string, error) {
+	switch option.Type {
+	case IntOption:
+		if _, err := strconv.Atoi(value); err != nil {
+			return "", fmt.Errorf("%q is not an integer", value)
+		}
+	case EnumOption:
+		if !contains(option.Enum, value) {
+			return "", fmt.Errorf("allowable values: %s", strings.Join(option.Enum, ", "))
+		}
+	case ColorOption:
+		return ParseColor(value)
+	case IdentifierOption:
+		if !identifier.MatchString(value) || pythonKeywords[value] {
+			return "", fmt.Errorf("%q is not a valid Python name", value)
+		}
+	case FontOption:
+		font, err := ParseFont(value)
+		if err != nil {
+			return "", err
+		}
+		return font.String(), nil
+	case ValuesOption:
+		if len(strings.TrimSpace(value)) < 1 {
+			return "", fmt.Errorf("value is empty")
+		}
+	}
+	return value, nil
+}
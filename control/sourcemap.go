@@ -0,0 +1,74 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// SourceRange is a run of lines of the build, numbered from 1, and the
+// part of the project they were generated from: "widget ok", "menu File",
+// "setting TITLE", or "code" and the name of a fixed part such as imports.
+type SourceRange struct {
+	Start  int    `json:"start"`
+	End    int    `json:"end"`
+	Source string `json:"source"`
+}
+
+// SourceMap maps the lines of a build back to the project.
+type SourceMap []SourceRange
+
+// Lookup returns the source of a line of the build, or "" if none.
+func (sources SourceMap) Lookup(line int) string {
+	for _, lines := range sources {
+		if line >= lines.Start && line <= lines.End {
+			return lines.Source
+		}
+	}
+	return ""
+}
+
+// sourceMark is where the code of a part of the project starts in the
+// build, as a byte offset while generating and as a line afterwards.
+type sourceMark struct {
+	source string
+	start  int
+}
+
+// markLines converts the byte offsets of marks in code to line indexes,
+// shifted by the lines that will come before code.
+func markLines(code []byte, marks []sourceMark, shift int) {
+	for index := range marks {
+		marks[index].start = bytes.Count(code[:marks[index].start], []byte("\n")) + shift
+	}
+}
+
+// newSourceMap builds the map from marks holding line indexes, where
+// lineMap gives the final index of each line after restyling.
+func newSourceMap(marks []sourceMark, lineMap []int) SourceMap {
+	var sources SourceMap
+	for index, mark := range marks {
+		end := len(lineMap) - 1
+		if index+1 < len(marks) {
+			end = marks[index+1].start
+		}
+		lines := SourceRange{Start: lineMap[mark.start] + 1, End: lineMap[end], Source: mark.source}
+		if lines.End >= lines.Start {
+			sources = append(sources, lines)
+		}
+	}
+	return sources
+}
+
+// writeSourceMap leaves the map of the current build where the designer
+// can read it.
+func (app *AppParser) writeSourceMap() error {
+	raw, err := json.Marshal(app.SourceMap)
+	if err != nil {
+		return err
+	}
+	return app.Utils.WriteFile(fmt.Sprintf("%s.map", app.Project), raw)
+}
@@ -0,0 +1,249 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CodeStyle is a project's profile for the generated Python, kept in the
+// project's STYLE entry.
+type CodeStyle struct {
+	Indent     int    // spaces per indentation level
+	Quotes     string // single or double
+	Comments   string // full, minimal (no comment per widget) or none
+	LineLength int    // calls that fit are joined onto one line; 0 never joins
+	TypeHints  bool
+	Docstrings bool
+	Imports    string // star, explicit or qualified
+}
+
+// styleOptions are the settings of the STYLE entry, with their defaults.
+var styleOptions = []struct {
+	Option
+	Default string
+}{
+	{Option{Name: "indent", Type: IntOption}, "4"},
+	{Option{Name: "quotes", Type: EnumOption, Enum: []string{"single", "double"}}, "single"},
+	{Option{Name: "comments", Type: EnumOption, Enum: []string{"full", "minimal", "none"}}, "full"},
+	{Option{Name: "linelength", Type: IntOption}, "0"},
+	{Option{Name: "typehints", Type: EnumOption, Enum: []string{"true", "false"}}, "false"},
+	{Option{Name: "docstrings", Type: EnumOption, Enum: []string{"true", "false"}}, "false"},
+	{Option{Name: "imports", Type: EnumOption, Enum: []string{"star", "explicit", "qualified"}}, "star"},
+}
+
+// SetStyle changes the code style settings given as name|@|value pairs.
+func (app *AppParser) SetStyle(update []string) error {
+	settings := map[string]string{}
+	for _, pair := range update {
+		kv := strings.Split(pair, "|@|")
+		if len(kv) != 2 {
+			return fmt.Errorf("malformed style setting %q", pair)
+		}
+		value, err := normalizeStyle(kv[0], strings.TrimSpace(kv[1]))
+		if err != nil {
+			return err
+		}
+		settings[kv[0]] = value
+	}
+	if app.MapBuild["STYLE"] == nil {
+		app.MapBuild["STYLE"] = make(map[string]interface{})
+	}
+	for name, value := range settings {
+		app.MapBuild["STYLE"][name] = value
+	}
+	return nil
+}
+
+func normalizeStyle(name, value string) (string, error) {
+	for _, setting := range styleOptions {
+		if setting.Name != name {
+			continue
+		}
+		normalized, err := setting.Normalize(strings.ToLower(value))
+		if err != nil {
+			return "", &OptionError{name, err.Error()}
+		}
+		number, _ := intAttr(map[string]interface{}{name: normalized}, name)
+		if name == "indent" && (number < 1 || number > 8) {
+			return "", &OptionError{name, "use 1 to 8 spaces"}
+		}
+		if name == "linelength" && number != 0 && number < 40 {
+			return "", &OptionError{name, "use 0 or at least 40"}
+		}
+		return normalized, nil
+	}
+	return "", &OptionError{name, "not a style setting"}
+}
+
+// codeStyle reads the project's code style, falling back to the defaults
+// for settings it doesn't have.
+func (cont AppController) codeStyle() CodeStyle {
+	settings := map[string]interface{}{}
+	for _, setting := range styleOptions {
+		settings[setting.Name] = setting.Default
+		if value, isSet := cont.MapBuild["STYLE"][setting.Name]; isSet {
+			settings[setting.Name] = value
+		}
+	}
+	indent, _ := intAttr(settings, "indent")
+	if indent < 1 {
+		indent = 4
+	}
+	lineLength, _ := intAttr(settings, "linelength")
+	return CodeStyle{
+		Indent:     indent,
+		Quotes:     fmt.Sprint(settings["quotes"]),
+		Comments:   fmt.Sprint(settings["comments"]),
+		LineLength: lineLength,
+		TypeHints:  settings["typehints"] == "true",
+		Docstrings: settings["docstrings"] == "true",
+		Imports:    fmt.Sprint(settings["imports"]),
+	}
+}
+
+// pythonSegment is a run of code, a string literal or a comment.
+type pythonSegment struct {
+	kind byte // 'c'ode, 's'tring or '#'
+	text string
+}
+
+// splitPython splits Python source into code, string literals and comments,
+// enough to restyle generated code without touching the text it quotes.
+func splitPython(source string) []pythonSegment {
+	var segments []pythonSegment
+	start := 0
+	flush := func(end int) {
+		if end > start {
+			segments = append(segments, pythonSegment{'c', source[start:end]})
+		}
+		start = end
+	}
+	for index := 0; index < len(source); {
+		switch char := source[index]; char {
+		case '#':
+			flush(index)
+			end := strings.IndexByte(source[index:], '\n')
+			if end < 0 {
+				end = len(source) - index
+			}
+			segments = append(segments, pythonSegment{'#', source[index : index+end]})
+			index += end
+			start = index
+		case '\'', '"':
+			flush(index)
+			quote := string(char)
+			if strings.HasPrefix(source[index:], strings.Repeat(quote, 3)) {
+				quote = strings.Repeat(quote, 3)
+			}
+			end := index + len(quote)
+			for end < len(source) && !strings.HasPrefix(source[end:], quote) {
+				if source[end] == '\\' {
+					end++
+				} else if source[end] == '\n' && len(quote) == 1 {
+					break
+				}
+				end++
+			}
+			end += len(quote)
+			if end > len(source) {
+				end = len(source)
+			}
+			segments = append(segments, pythonSegment{'s', source[index:end]})
+			index = end
+			start = index
+		default:
+			index++
+		}
+	}
+	flush(len(source))
+	return segments
+}
+
+// restyle applies the quote and comment settings to generated code. The
+// returned slice gives the new index of each line, or of the next line
+// kept when it was dropped, followed by the new number of lines.
+func restyle(source string, style CodeStyle) (string, []int) {
+	var styled strings.Builder
+	for _, segment := range splitPython(source) {
+		switch {
+		case segment.kind == '#' && style.Comments == "none":
+			continue
+		case segment.kind == 's' && style.Quotes == "double":
+			styled.WriteString(doubleQuote(segment.text))
+		default:
+			styled.WriteString(segment.text)
+		}
+	}
+
+	// Drop the lines only comments were on.
+	lines := strings.Split(styled.String(), "\n")
+	original := strings.Split(source, "\n")
+	lineMap := make([]int, 0, len(lines)+1)
+	kept := lines[:0]
+	for index, line := range lines {
+		lineMap = append(lineMap, len(kept))
+		if style.Comments != "none" {
+			kept = append(kept, line)
+		} else if len(strings.TrimSpace(line)) > 0 || index >= len(original) ||
+			!strings.HasPrefix(strings.TrimSpace(original[index]), "#") {
+			kept = append(kept, strings.TrimRight(line, " \t"))
+		}
+	}
+	return strings.Join(kept, "\n"), append(lineMap, len(kept))
+}
+
+// doubleQuote rewrites a single-quoted literal with double quotes when that
+// needs no new escapes.
+func doubleQuote(literal string) string {
+	if !strings.HasPrefix(literal, "'") || strings.HasPrefix(literal, "'''") ||
+		len(literal) < 2 || !strings.HasSuffix(literal, "'") {
+		return literal
+	}
+	content := literal[1 : len(literal)-1]
+	if strings.Contains(content, `"`) {
+		return literal
+	}
+	return `"` + strings.Replace(content, `\'`, "'", -1) + `"`
+}
+
+// joinCalls puts each call whose arguments are on separate lines onto one
+// line when it fits within the line length.
+func joinCalls(block string, lineLength int) string {
+	if lineLength < 1 {
+		return block
+	}
+	lines := strings.Split(block, "\n")
+	var joined []string
+	for index := 0; index < len(lines); index++ {
+		line := lines[index]
+		if !strings.HasSuffix(line, "(") {
+			joined = append(joined, line)
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		end := index + 1
+		for end < len(lines) && lines[end] != indent+")" &&
+			!strings.HasSuffix(lines[end], "(") {
+			end++
+		}
+		if end >= len(lines) || lines[end] != indent+")" {
+			joined = append(joined, line)
+			continue
+		}
+		var args []string
+		for _, arg := range lines[index+1 : end] {
+			args = append(args, strings.TrimSuffix(strings.TrimSpace(arg), ","))
+		}
+		call := line + strings.Join(args, ", ") + ")"
+		if len(call) > lineLength {
+			joined = append(joined, line)
+			continue
+		}
+		joined = append(joined, call)
+		index = end
+	}
+	return strings.Join(joined, "\n")
+}
@@ -0,0 +1,64 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRestyle(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		style   CodeStyle
+		source  string
+		want    string
+		lineMap []int
+	}{
+		{"defaults", CodeStyle{Quotes: "single", Comments: "full"},
+			"x = 'a'  # note\n", "x = 'a'  # note\n", []int{0, 1, 2}},
+		{"double quotes", CodeStyle{Quotes: "double", Comments: "full"},
+			`x = f('a', 'it\'s', 'say "hi"', "b", '''doc''')`,
+			`x = f("a", "it's", 'say "hi"', "b", '''doc''')`, []int{0, 1}},
+		{"comments keep their quotes", CodeStyle{Quotes: "double", Comments: "full"},
+			"# it's 'quoted'\nx = '#fff'", "# it's 'quoted'\nx = \"#fff\"", []int{0, 1, 2}},
+		{"no comments", CodeStyle{Quotes: "single", Comments: "none"},
+			"a = 1\n# c\nb = 2  # note\n", "a = 1\nb = 2\n", []int{0, 1, 1, 2, 3}},
+		{"hash in a string", CodeStyle{Quotes: "single", Comments: "none"},
+			"color = '#fff'  # white", "color = '#fff'", []int{0, 1}},
+		{"blank lines stay", CodeStyle{Quotes: "single", Comments: "none"},
+			"a = 1\n\n    # c\n\nb = 2", "a = 1\n\n\nb = 2", []int{0, 1, 2, 2, 3, 4}},
+	} {
+		got, lineMap := restyle(test.source, test.style)
+		if got != test.want {
+			t.Errorf("%s:\n got %q\nwant %q", test.name, got, test.want)
+		}
+		if !reflect.DeepEqual(lineMap, test.lineMap) {
+			t.Errorf("%s: line map %v, want %v", test.name, lineMap, test.lineMap)
+		}
+	}
+}
+
+func TestJoinCalls(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		block      string
+		lineLength int
+		want       string
+	}{
+		{"fits", "x = f(\n    a,\n    b,\n)", 79, "x = f(a, b)"},
+		{"exactly fits", "x = f(\n    a,\n    b,\n)", 11, "x = f(a, b)"},
+		{"too long", "x = f(\n    a,\n    b,\n)", 10, "x = f(\n    a,\n    b,\n)"},
+		{"never joined", "x = f(\n    a,\n)", 0, "x = f(\n    a,\n)"},
+		{"indented", "\tdef g():\n\t\tf(\n\t\t\ta,\n\t\t)\n\t\treturn", 79, "\tdef g():\n\t\tf(a)\n\t\treturn"},
+		{"several calls", "f(\n    a,\n)\ng(\n    b,\n)", 79, "f(a)\ng(b)"},
+		{"nested calls are left alone", "g(\n    h(\n        1,\n    ),\n)", 79, "g(\n    h(\n        1,\n    ),\n)"},
+		{"unclosed", "f(\n    a,", 79, "f(\n    a,"},
+		{"closed at another indent", "    f(\n        a,\n)", 79, "    f(\n        a,\n)"},
+	} {
+		if got := joinCalls(test.block, test.lineLength); got != test.want {
+			t.Errorf("%s:\n got %q\nwant %q", test.name, got, test.want)
+		}
+	}
+}
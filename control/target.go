@@ -0,0 +1,185 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// targetNames are the settings of the project's TARGET entry: the oldest
+// Tk and Python the generated app has to run with. Unset, the app is only
+// expected to run where Visipy itself does and nothing is checked.
+var targetNames = []string{"tk", "python"}
+
+var targetTitles = map[string]string{"tk": "Tk", "python": "Python"}
+
+// oldestTargets are the oldest versions the generated code can run with at
+// all: ttk needs Tk 8.5.
+var oldestTargets = map[string]version{"tk": {8, 5}, "python": {3, 0}}
+
+// ttkRequirements are the versions the ttk classes that came after Tk 8.5
+// need, for custom widgets that import them from tkinter.ttk.
+var ttkRequirements = map[string]map[string]string{
+	"Spinbox": {"tk": "8.5.9", "python": "3.7"},
+}
+
+var (
+	versionPattern = regexp.MustCompile(`^\d+(\.\d+){1,2}$`)
+	traceAdd       = regexp.MustCompile(`\.trace_(add|remove)\(\s*(['"])(write|read|unset)(['"])`)
+	traceInfo      = regexp.MustCompile(`\.trace_info\(`)
+)
+
+// version is a dotted version number such as 8.5 or 3.6.9.
+type version []int
+
+func parseVersion(value string) (version, error) {
+	if !versionPattern.MatchString(value) {
+		return nil, fmt.Errorf("%q is not a version such as 8.5 or 3.6", value)
+	}
+	var parsed version
+	for _, part := range strings.Split(value, ".") {
+		number, _ := strconv.Atoi(part)
+		parsed = append(parsed, number)
+	}
+	return parsed, nil
+}
+
+// before reports whether v is older than other; missing parts count as 0.
+func (v version) before(other version) bool {
+	for index := 0; index < len(v) || index < len(other); index++ {
+		var a, b int
+		if index < len(v) {
+			a = v[index]
+		}
+		if index < len(other) {
+			b = other[index]
+		}
+		if a != b {
+			return a < b
+		}
+	}
+	return false
+}
+
+// SetTarget changes the target versions given as name|@|value pairs. An
+// empty value clears a setting.
+func (app *AppParser) SetTarget(update []string) error {
+	settings := map[string]string{}
+	for _, pair := range update {
+		kv := strings.Split(pair, "|@|")
+		if len(kv) != 2 {
+			return fmt.Errorf("malformed target setting %q", pair)
+		}
+		name, value := kv[0], strings.TrimSpace(kv[1])
+		if !contains(targetNames, name) {
+			return &OptionError{name, "not a target setting, use tk or python"}
+		}
+		if len(value) > 0 {
+			parsed, err := parseVersion(value)
+			if err != nil {
+				return &OptionError{name, err.Error()}
+			}
+			if parsed.before(oldestTargets[name]) {
+				return &OptionError{name, "generated code needs Tk 8.5 and Python 3"}
+			}
+		}
+		settings[name] = value
+	}
+
+	if app.MapBuild["TARGET"] == nil {
+		app.MapBuild["TARGET"] = make(map[string]interface{})
+	}
+	for name, value := range settings {
+		if len(value) < 1 {
+			delete(app.MapBuild["TARGET"], name)
+		} else {
+			app.MapBuild["TARGET"][name] = value
+		}
+	}
+	return nil
+}
+
+// target returns the project's target version of tk or python, or nil if
+// it has none.
+func (cont AppController) target(name string) version {
+	value, _ := cont.MapBuild["TARGET"][name].(string)
+	parsed, _ := parseVersion(value)
+	return parsed
+}
+
+// supports reports whether the target versions have what needs asks for,
+// and if not, what is missing, e.g. "Tk 8.6 (the project targets 8.5)".
+func (cont AppController) supports(needs map[string]string) (string, bool) {
+	var missing []string
+	for _, name := range targetNames {
+		needed, err := parseVersion(needs[name])
+		target := cont.target(name)
+		if err == nil && target != nil && target.before(needed) {
+			missing = append(missing, fmt.Sprintf("%s %s (the project targets %s)",
+				targetTitles[name], needs[name], cont.MapBuild["TARGET"][name]))
+		}
+	}
+	return strings.Join(missing, " and "), len(missing) < 1
+}
+
+// TargetWarnings lists what the project uses that its target versions
+// don't have. Variable traces are rewritten instead, see substituteTarget.
+func (app *AppParser) TargetWarnings() []LayoutWarning {
+	var warnings []LayoutWarning
+	png := map[string]string{"tk": "8.6"}
+	isPNG := func(path string) bool { return strings.EqualFold(filepath.Ext(path), ".png") }
+
+	if path, _ := app.MapBuild["ICON"]["iconpath"].(string); app.HaveIcon && isPNG(path) {
+		if missing, ok := app.supports(png); !ok {
+			warnings = append(warnings, LayoutWarning{"ICON", "PNG images need " + missing + ", use a GIF"})
+		}
+	}
+	for name, value := range app.MapBuild {
+		if _, isWidget := value["row"]; !isWidget {
+			continue
+		}
+		widgetType, _ := value["widget"].(string)
+		schema, _ := app.lookupWidget(widgetType)
+		if missing, ok := app.supports(schema.Requires); !ok {
+			warnings = append(warnings, LayoutWarning{name, widgetType + " needs " + missing})
+		}
+		for _, option := range schema.AllOptions() {
+			path, _ := value[option.Name].(string)
+			if option.Type != ImageOption || !isPNG(path) {
+				continue
+			}
+			if missing, ok := app.supports(png); !ok {
+				warnings = append(warnings, LayoutWarning{name, "PNG images need " + missing + ", use a GIF"})
+			}
+		}
+	}
+	sort.Slice(warnings, func(i, j int) bool { return warnings[i].Widget < warnings[j].Widget })
+	return warnings
+}
+
+// Lint returns the layout warnings of the current project followed by its
+// target version warnings.
+func (app *AppParser) Lint() []LayoutWarning {
+	return append(app.LintLayout(), app.TargetWarnings()...)
+}
+
+// substituteTarget rewrites code for the project's target Python: before
+// 3.6 variables have trace_variable and friends instead of trace_add.
+func (cont AppController) substituteTarget(code string) string {
+	target := cont.target("python")
+	if target == nil || !target.before(version{3, 6}) {
+		return code
+	}
+	code = traceAdd.ReplaceAllStringFunc(code, func(call string) string {
+		match := traceAdd.FindStringSubmatch(call)
+		method := map[string]string{"add": "trace_variable", "remove": "trace_vdelete"}[match[1]]
+		return "." + method + "(" + match[2] + match[3][:1] + match[4]
+	})
+	return traceInfo.ReplaceAllString(code, ".trace_vinfo(")
+}
@@ -0,0 +1,108 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"strings"
+	"testing"
+)
+
+// traceTemplate is a custom widget template using the variable traces
+// Python 3.6 added.
+const traceTemplate = `		self.{{.name}}_level = IntVar()
+		self.{{.name}}_trace = self.{{.name}}_level.trace_add('write', self.changed)
+		self.{{.name}}_level.trace_remove( "write", self.{{.name}}_trace)
+		print(self.{{.name}}_level.trace_info())
+		self.{{.name}} = {{.widget}}(
+			{{.master}},
+			variable=self.{{.name}}_level,
+		)
+		self.{{.name}}.grid(row={{.row}}, column={{.column}})`
+
+func TestTargetRewritesTraces(t *testing.T) {
+	for _, test := range []struct {
+		target string
+		want   []string
+	}{
+		{"python|@|3.5", []string{
+			"self.g_level.trace_variable('w', self.changed)",
+			`self.g_level.trace_vdelete("w", self.g_trace)`,
+			"print(self.g_level.trace_vinfo())",
+		}},
+		{"python|@|3.6", []string{
+			"self.g_level.trace_add('write', self.changed)",
+			`self.g_level.trace_remove( "write", self.g_trace)`,
+			"print(self.g_level.trace_info())",
+		}},
+		{"tk|@|8.5", []string{"self.g_level.trace_add('write', self.changed)"}},
+	} {
+		app := newTestApp(t)
+		app.projectWidgets = map[string]WidgetSchema{
+			"Gauge": {Name: "Gauge", Class: "Gauge", Template: traceTemplate},
+		}
+		for _, command := range []string{
+			"ADD|$|Gauge|$|name|@|g|:|row|@|0|:|column|@|0",
+			"TARGET|$|" + test.target,
+		} {
+			if err := app.ApplyCommand(strings.Split(command, "|$|")); err != nil {
+				t.Fatalf("%s: %v", command, err)
+			}
+		}
+		if err := app.generate(); err != nil {
+			t.Fatal(err)
+		}
+		code := app.Build.String()
+		for _, want := range test.want {
+			if !strings.Contains(code, want) {
+				t.Errorf("%s: code lacks %q:\n%s", test.target, want, code)
+			}
+		}
+	}
+}
+
+func TestTargetWarnings(t *testing.T) {
+	// The images are only named, as the warnings don't open them.
+	app := newTestApp(t, "ADD|$|Button|$|name|@|ok|:|row|@|2|:|column|@|0")
+	app.projectWidgets = map[string]WidgetSchema{
+		"Gauge": {Name: "Gauge", Class: "Gauge", Requires: map[string]string{"tk": "8.6", "python": "3.7"}},
+	}
+	for name, widget := range map[string]map[string]interface{}{
+		"logo": {"widget": "Image", "name": "logo", "row": "0", "column": "0", "image": "logo.PNG"},
+		"gif":  {"widget": "Image", "name": "gif", "row": "1", "column": "0", "image": "logo.gif"},
+		"g":    {"widget": "Gauge", "name": "g", "row": "3", "column": "0"},
+		"ICON": {"iconpath": "icon.png"},
+	} {
+		app.MapBuild[name] = widget
+	}
+	app.HaveIcon = true
+
+	for _, test := range []struct {
+		target string
+		want   []string
+	}{
+		{"", nil},
+		{"tk|@|8.6|:|python|@|3.7", nil},
+		{"tk|@|8.5", []string{
+			"ICON: PNG images need Tk 8.6 (the project targets 8.5), use a GIF",
+			"g: Gauge needs Tk 8.6 (the project targets 8.5)",
+			"logo: PNG images need Tk 8.6 (the project targets 8.5), use a GIF",
+		}},
+		{"tk|@|8.6|:|python|@|3.5", []string{
+			"g: Gauge needs Python 3.7 (the project targets 3.5)",
+		}},
+	} {
+		if len(test.target) > 0 {
+			if err := app.ApplyCommand([]string{"TARGET", test.target}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var got []string
+		for _, warning := range app.TargetWarnings() {
+			got = append(got, warning.String())
+		}
+		if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+			t.Errorf("%q:\n got %q\nwant %q", test.target, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package control
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// TemplateDirName is the directory, next to a project or in the user's
+// config directory, that code generation templates are overridden from.
+const TemplateDirName = "templates"
+
+var templateOverrides = map[string]string{}
+
+// LoadTemplateDir overrides built-in templates for all projects with the
+// <name>.tmpl files in dir. A missing directory is not an error.
+func LoadTemplateDir(dir string) error {
+	templates, err := readTemplateDir(dir)
+	if err != nil {
+		return err
+	}
+	for name, text := range templates {
+		templateOverrides[name] = text
+	}
+	return nil
+}
+
+// readTemplateDir reads the <name>.tmpl files in dir, refusing any that
+// don't parse or don't override a built-in template.
+func readTemplateDir(dir string) (map[string]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	templates := make(map[string]string, len(paths))
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), ".tmpl")
+		if _, exists := builtinTemplates[name]; !exists {
+			return nil, fmt.Errorf("%s: unknown template %q", path, name)
+		}
+		raw, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := template.New(name).Funcs(templateFuncs(CodeStyle{Indent: 4})).Parse(string(raw)); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		templates[name] = string(raw)
+	}
+	return templates, nil
+}
+
+// UserTemplateDir returns the per-user directory of template overrides.
+func UserTemplateDir() (string, error) {
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(config, "visipy", TemplateDirName), nil
+}
+
+// WriteDefaultTemplates writes the built-in templates to dir as a starting
+// point for overrides, refusing to replace existing files.
+func WriteDefaultTemplates(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for name, text := range builtinTemplates {
+		path := filepath.Join(dir, name+".tmpl")
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			return err
+		}
+		_, err = file.WriteString(text)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// templateFuncs are the helpers available to every template:
+//
+//	snake_case  "MyApp" -> "my_app"
+//	quote       a single-quoted Python string literal
+//	indent      indent N text: prefixes each line with N indentation levels
+//	typehints   whether the code style asks for type hints
+//	docstrings  whether the code style asks for docstrings
+func templateFuncs(style CodeStyle) template.FuncMap {
+	return template.FuncMap{
+		"snake_case": snakeCase,
+		"quote":      quote,
+		"indent": func(levels int, text string) string {
+			prefix := strings.Repeat(" ", style.Indent*levels)
+			lines := strings.Split(text, "\n")
+			for index, line := range lines {
+				if len(line) > 0 {
+					lines[index] = prefix + line
+				}
+			}
+			return strings.Join(lines, "\n")
+		},
+		"typehints":  func() bool { return style.TypeHints },
+		"docstrings": func() bool { return style.Docstrings },
+	}
+}
+
+// parse prepares a template's text, with its tabs replaced by the
+// current indentation.
+func (cont AppController) parse(name, text string) (*template.Template, error) {
+	text = string(bytes.ReplaceAll([]byte(text), []byte{0x09}, cont.I1b))
+	return template.New(name).Funcs(templateFuncs(cont.codeStyle())).Parse(text)
+}
+
+// render executes the named template with data. The project's override
+// of the template comes first, then the user's, then the built-in one.
+func (cont AppController) render(out io.Writer, name string, data interface{}) error {
+	text, overridden := cont.projectTemplates[name]
+	if !overridden {
+		text, overridden = templateOverrides[name]
+	}
+	if !overridden {
+		text = builtinTemplates[name]
+	}
+	tmpl, err := cont.parse(name, text)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(out, data)
+}
+
+// quote is the quote template helper. An unset value stays "<no value>",
+// as it prints without quote, so the widget templates still drop its line.
+func quote(value interface{}) string {
+	if value == nil {
+		return "<no value>"
+	}
+	return pythonString(fmt.Sprint(value))
+}
+
+// snakeCase converts a name such as "MyApp" or "My App" to "my_app".
+func snakeCase(value string) string {
+	var snake []rune
+	runes := []rune(strings.TrimSpace(value))
+	for index, char := range runes {
+		switch {
+		case unicode.IsSpace(char) || char == '-':
+			char = '_'
+		case unicode.IsUpper(char):
+			if index > 0 && (unicode.IsLower(runes[index-1]) ||
+				index+1 < len(runes) && unicode.IsLower(runes[index+1]) && unicode.IsUpper(runes[index-1])) {
+				snake = append(snake, '_')
+			}
+			char = unicode.ToLower(char)
+		}
+		if char == '_' && len(snake) > 0 && snake[len(snake)-1] == '_' {
+			continue
+		}
+		snake = append(snake, char)
+	}
+	return string(snake)
+}
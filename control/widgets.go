@@ -9,147 +9,93 @@ import (
 	"strings"
 )
 
-func (cont AppController) getAppColor() string {
-	color := fmt.Sprintf("%[1]s# App Color\n%[1]s", cont.I2)
-	return color + "self.master.configure(bg='{{.appcolor}}')\n\n"
-}
+// builtinTemplates generate each part of the Python source, in the order
+// they are written. A tab stands for one level of indentation. The data
+// each template is executed with:
+//
+//	header      META: .classname, .title, .module, .version, .author, .license
+//	imports     .imports: the import statements, one per line
+//	classinit   META
+//	apptitle    META
+//	appcolor    APPCOLOR: .appcolor
+//	dimensions  DIMENSIONS: .dimensions
+//	menucolor   MENUCOLOR: .foreground, .background
+//	icon        ICON: .iconpath
+//	menu        .title, .var (the menu's variable prefix), .submenus
+//	widget      the widget's attributes, .widget being its Python class and
+//	            .master the window or container it's placed in; unset
+//	            options are missing and their lines are dropped
+//	image       as widget, for Image widgets
+//	methods     .methods: each handler's .Name and .Signature
+//	quit        the whole project
+//	gui         the whole project, e.g. .THEME.theme and .META.classname
+//	main        the whole project
+var builtinTemplates = map[string]string{
+	"header": `{{if or .version .author .license}}"""{{.module}} - {{.title}}
+
+{{with .version}}Version: {{.}}
+{{end}}{{with .author}}Author: {{.}}
+{{end}}{{with .license}}License: {{.}}
+{{end}}"""
+
+{{with .version}}__version__ = {{quote .}}
+{{end}}{{with .author}}__author__ = {{quote .}}
+{{end}}{{with .license}}__license__ = {{quote .}}
+{{end}}
+{{end}}`,
+	"imports": `{{range .imports}}{{.}}
+{{end}}
+
+`,
+	"classinit": `class {{.classname}}:
+{{if docstrings}}	"""The {{.title}} application window."""
+
+{{end}}	def __init__(self, master{{if typehints}}: Tk{{end}}){{if typehints}} -> None{{end}}:
+		self.master = master
 
-func (cont AppController) getAppTitle() string {
-	title := fmt.Sprintf("%[1]s# App Title\n%[1]s", cont.I2)
-	return title + "self.master.title('{{.title}}')\n\n"
-}
+`,
+	"apptitle": `		# App Title
+		self.master.title({{quote .title}})
 
-func (cont AppController) getAppDimensions() string {
-	dim := fmt.Sprintf("%[1]s# Overall Dimensions\n%[1]sself.master", cont.I2)
-	return dim + ".geometry('{{.dimensions}}')\n\n"
-}
+`,
+	"appcolor": `		# App Color
+		self.master.configure(bg='{{.appcolor}}')
 
-func (cont AppController) getMenuInit() string {
-	menuInit := []byte(
-		`		# Window Menu Color
+`,
+	"dimensions": `		# Overall Dimensions
+		self.master.geometry('{{.dimensions}}')
+
+`,
+	"menucolor": `		# Window Menu Color
 		menu = Menu(self.master)
 		menu.config(foreground='{{.foreground}}', background='{{.background}}')
 		self.master.config(menu=menu)
 
-`)
-	return string(bytes.ReplaceAll(menuInit, []byte{0x09}, cont.I1b))
-}
-
-func (cont AppController) getMenuItem(title string, numberSubmenus int8) string {
-	var menu bytes.Buffer
-	var titleLower = strings.ToLower(title)
-	menu.WriteString(fmt.Sprintf("%s# %s\n", cont.I2, title))
-	menu.WriteString(fmt.Sprintf("%s%s_menu = Menu(menu)\n", cont.I2, titleLower))
-
-	var index int8
-	for index = 0; index < numberSubmenus; index++ {
-		menu.WriteString(fmt.Sprintf("%s%s_menu.add_command(\n", cont.I2, titleLower))
-		menu.WriteString(fmt.Sprintf("%s%slabel='{{.submenu%d}}',\n", cont.I1, cont.I2, index))
-		menu.WriteString(fmt.Sprintf("%s%scommand=quit_\n%s)\n\n", cont.I1, cont.I2, cont.I2))
-	}
-
-	menu.WriteString(fmt.Sprintf("%smenu.add_cascade(label='%s', ", cont.I2, title))
-	menu.WriteString(fmt.Sprintf("menu=%s_menu)\n\n", titleLower))
-	return menu.String()
-}
-
-func (cont AppController) getMethods(methodNames []string) []byte {
-	todo := "TODO: Add handling code here"
-	var collection bytes.Buffer
-	for _, name := range methodNames {
-		collection.WriteString(fmt.Sprintf("%sdef %s(self):\n", cont.I1, name))
-		collection.WriteString(fmt.Sprintf("%s\"\"\" %s \"\"\"\n", cont.I2, todo))
-		msg := fmt.Sprintf("%sprint('Handle %s here')\n\n", cont.I2, name)
-		collection.WriteString(msg)
-	}
-	return collection.Bytes()
-}
-
-func (cont AppController) getAppIcon() string {
-	icon := []byte(
-		`		# ICON
-		icon_path = '{{.iconpath}}'
+`,
+	"icon": `		# ICON
+		icon_path = {{quote .iconpath}}
 		self.icon = PhotoImage(file=icon_path)
 		master.iconphoto(False, self.icon)
 
 
-`)
-	return string(bytes.ReplaceAll(icon, []byte{0x09}, cont.I1b))
-}
-
-func (cont AppController) getImports() []byte {
-	return []byte(
-		`from tkinter import *
-
-
-`)
-}
-
-func (cont AppController) getSysImport() []byte {
-	return []byte(
-		`from sys import exit
-`)
-}
-
-func (cont AppController) getStyleImport() []byte {
-	return []byte(
-		`from tkinter.ttk import Style
-`)
-}
-
-func (cont AppController) getClassInit() string {
-	classInit := []byte(
-		`class {{.title}}:
-	def __init__(self, master):
-		self.master = master
-
-`)
-	return string(bytes.ReplaceAll(classInit, []byte{0x09}, cont.I1b))
-}
-
-func (cont AppController) getQuit() []byte {
-	qu := []byte(
-		`
-def quit_():
-	exit()
-
-`)
-	return bytes.ReplaceAll(qu, []byte{0x09}, cont.I1b)
-}
-
-func (cont AppController) getGui() string {
-	tk := []byte(
-		`
-# App Theme
-def run_gui():
-	root = Tk()
-	root.style = Style()
-	root.style.theme_use('{{.THEME.theme}}')
-	{{.TITLE.title}}(root)
-	root.mainloop()
-
-
-`)
-	return string(bytes.ReplaceAll(tk, []byte{0x09}, cont.I1b))
-}
+`,
+	"menu": `		# {{.title}}
+		{{.var}}_menu = Menu(menu)
+{{range .submenus}}		{{$.var}}_menu.add_command(
+			label={{quote .}},
+			command=quit_
+		)
 
-func (cont AppController) getMain() []byte {
-	main := []byte(
-		`if __name__ == '__main__':
-	run_gui()
-`)
-	return bytes.ReplaceAll(main, []byte{0x09}, cont.I1b)
-}
+{{end}}		menu.add_cascade(label={{quote .title}}, menu={{.var}}_menu)
 
-func (cont AppController) getWidget() string {
-	anonWidget := []byte(
-		`		# {{.name}}
+`,
+	"widget": `		# {{.name}}
 		self.{{.name}} = {{.widget}}(
-			master,
+			{{.master}},
 			foreground='{{.foreground}}',
 			background='{{.background}}',
-			font='{{.font}}',
-			text='{{.text}}',
+			font={{.font}},
+			text={{quote .text}},
 			activeforeground='{{.activeforeground}}',
 			activebackground='{{.activebackground}}',
 			anchor={{.anchor}},
@@ -157,7 +103,7 @@ func (cont AppController) getWidget() string {
 			highlightcolor='{{.highlightcolor}}',
 			indicatoron={{.indicatoron}},
 			selectcolor='{{.selectcolor}}',
-			show='{{.show}}',
+			show={{quote .show}},
 			insertbackground='{{.insertbackground}}',
 			selectforeground='{{.selectforeground}}',
 			selectbackground='{{.selectbackground}}',
@@ -192,23 +138,130 @@ func (cont AppController) getWidget() string {
 			padx={{.padx}},
 			pady={{.pady}},
 			sticky={{.sticky}},
-		)`)
-
-	return string(bytes.ReplaceAll(anonWidget, []byte{0x09}, cont.I1b))
-}
-
-func (cont AppController) getImgWidget() string {
-	anonWidget := []byte(
-		`		# {{.name}}
-		self.{{.name}} = Label(master)
-        {{.name}}_gif = '{{.image}}'
-        self.{{.name}}.img = PhotoImage(file={{.name}}_gif)
-        self.{{.name}}.config(
+		)`,
+	"image": `		# {{.name}}
+		self.{{.name}} = Label({{.master}})
+		{{.name}}_gif = {{quote .image}}
+		self.{{.name}}.img = PhotoImage(file={{.name}}_gif)
+		self.{{.name}}.config(
 			background='{{.background}}',
 			borderwidth={{.borderwidth}},
 			image=self.{{.name}}.img
 		)
 
+		self.{{.name}}.grid(
+			row={{.row}},
+			rowspan={{.rowspan}},
+			column={{.column}},
+			columnspan={{.columnspan}},
+			padx={{.padx}},
+			pady={{.pady}},
+			sticky={{.sticky}},
+		)`,
+	"methods": `{{range .methods}}	def {{.Name}}{{.Signature}}{{if typehints}} -> None{{end}}:
+		""" TODO: Add handling code here """
+		print('Handle {{.Name}} here')
+
+{{end}}`,
+	"quit": `
+def quit_(){{if typehints}} -> None{{end}}:
+{{if docstrings}}	"""Exit the application."""
+{{end}}	exit()
+
+`,
+	"gui": `
+# App Theme
+def run_gui(){{if typehints}} -> None{{end}}:
+{{if docstrings}}	"""Create the main window and run the event loop."""
+{{end}}	root = Tk()
+	root.style = Style()
+	root.style.theme_use('{{.THEME.theme}}')
+	{{.META.classname}}(root)
+	root.mainloop()
+
+
+`,
+	"main": `if __name__ == '__main__':
+	run_gui()
+`,
+}
+
+// handler is a method generated for a widget option such as command.
+type handler struct {
+	Name      string
+	Signature string
+}
+
+func hasMethod(methods []handler, name string) bool {
+	for _, method := range methods {
+		if method.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// menuData is what the menu template is executed with.
+func menuData(title string, menu map[string]interface{}) map[string]interface{} {
+	submenus := make([]interface{}, len(menu))
+	for index := range submenus {
+		submenus[index] = menu[fmt.Sprintf("submenu%d", index)]
+	}
+	return map[string]interface{}{
+		"title":    title,
+		"var":      menuVar(title),
+		"submenus": submenus,
+	}
+}
+
+// menuVar derives the Python name of a menu's variable from its title,
+// which may hold spaces or punctuation: "Help Me" is help_me.
+func menuVar(title string) string {
+	name := []byte(strings.ToLower(title))
+	for index, char := range name {
+		if !(char == '_' || char >= 'a' && char <= 'z' || char >= '0' && char <= '9') {
+			name[index] = '_'
+		}
+	}
+	if len(name) < 1 || name[0] >= '0' && name[0] <= '9' {
+		name = append([]byte{'_'}, name...)
+	}
+	return string(name)
+}
+
+// getCustomWidget builds the template of a custom widget type that has
+// none of its own, with one keyword argument per option.
+func getCustomWidget(schema WidgetSchema) string {
+	var anonWidget bytes.Buffer
+	anonWidget.WriteString("\t\t# {{.name}}\n\t\tself.{{.name}} = {{.widget}}(\n\t\t\t{{.master}},\n")
+	for _, option := range schema.Options {
+		value := "{{." + option.Name + "}}"
+		switch option.Type {
+		case ColorOption:
+			value = "'" + value + "'"
+		case TextOption:
+			value = "{{quote ." + option.Name + "}}"
+		case IdentifierOption:
+			value = "self." + value
+		case ValuesOption:
+			value = "(" + value + ")"
+		case EnumOption:
+			// Upper case values are tkinter constants, others strings.
+			for _, allowed := range option.Enum {
+				if allowed != strings.ToUpper(allowed) {
+					value = "'" + value + "'"
+					break
+				}
+			}
+		}
+		keyword := option.Name
+		if pythonKeywords[keyword] {
+			keyword += "_"
+		}
+		anonWidget.WriteString("\t\t\t" + keyword + "=" + value + ",\n")
+	}
+	anonWidget.WriteString(`		)
+
 		self.{{.name}}.grid(
 			row={{.row}},
 			rowspan={{.rowspan}},
@@ -219,75 +272,107 @@ func (cont AppController) getImgWidget() string {
 			sticky={{.sticky}},
 		)`)
 
-	return string(bytes.ReplaceAll(anonWidget, []byte{0x09}, cont.I1b))
+	return anonWidget.String()
 }
 
 // SetWidget sets any TK widget into the current build.
-func (app *AppParser) SetWidget(widgetType string, update []string) {
+func (app *AppParser) SetWidget(widgetType string, update []string) error {
 
 	tmp := make(map[string]interface{})
 	for _, attr := range update {
 		kv := strings.Split(attr, "|@|")
+		if len(kv) != 2 {
+			return fmt.Errorf("malformed widget attribute %q", attr)
+		}
 		tmp[kv[0]] = kv[1]
 	}
+	if _, hasName := tmp["name"]; !hasName {
+		return fmt.Errorf("%s widget is missing a name", widgetType)
+	}
+
+	schema, known := app.lookupWidget(widgetType)
+	if !known {
+		return fmt.Errorf("unknown widget type %q", widgetType)
+	}
+	if err := schema.Validate(tmp); err != nil {
+		return err
+	}
+	if spec, hasFont := tmp["font"].(string); hasFont && len(spec) > 0 {
+		font, _ := ParseFont(spec)
+		if err := app.checkFontFamily(font); err != nil {
+			return err
+		}
+	}
+	for _, option := range schema.AllOptions() {
+		if path, set := tmp[option.Name].(string); set && option.Type == ImageOption {
+			if err := app.checkImage(option.Name, path); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Editing a widget keeps it in its container unless told otherwise.
+	name := tmp["name"].(string)
+	parent, hasParent := tmp["parent"].(string)
+	if !hasParent {
+		parent = parentOf(app.MapBuild[name])
+	}
+	if err := app.checkParent(name, parent); err != nil {
+		return err
+	}
+	delete(tmp, "parent")
+	if children := app.children(name); len(children) > 0 && !schema.Container {
+		return fmt.Errorf("%s is not a container but contains %s", widgetType, strings.Join(children, ", "))
+	}
 
-	app.MapBuild[tmp["name"].(string)] = make(map[string]interface{})
-	app.MapBuild[tmp["name"].(string)] = map[string]interface{}{
-		"foreground":       "",
-		"background":       "",
-		"font":             "",
-		"text":             "",
-		"activeforeground": "",
-		"activebackground": "",
-		"anchor":           "",
-		"command":          "",
-		"highlightcolor":   "",
-		"indicatoron":      "",
-		"selectcolor":      "",
-		"show":             "",
-		"insertbackground": "",
-		"selectforeground": "",
-		"selectbackground": "",
-		"relief":           "",
-		"justify":          "",
-		"image":            "",
-		"troughcolor":      "",
-		"orient":           "",
-		"sliderrelief":     "",
-		"values":           "",
-		"wrap":             "",
-		"selectmode":       "",
-		"activestyle":      "",
-		"sticky":           "",
-		"widget":           widgetType,
-		"name":             tmp["name"].(string),
-
-		"length":             -1,
-		"from":               -1,
-		"to":                 -1,
-		"tickinterval":       -1,
-		"sliderlength":       -1,
-		"width":              -1,
-		"height":             -1,
-		"borderwidth":        -1,
-		"highlightthickness": -1,
-		"selectborderwidth":  -1,
-		"insertontime":       -1,
-		"insertofftime":      -1,
-		"row":                -1,
-		"rowspan":            -1,
-		"column":             -1,
-		"columnspan":         -1,
-		"padx":               -1,
-		"pady":               -1,
+	app.MapBuild[name] = map[string]interface{}{
+		"widget": widgetType,
+		"name":   name,
+	}
+	for _, option := range schema.AllOptions() {
+		if option.Type == IntOption {
+			app.MapBuild[name][option.Name] = -1
+		} else {
+			app.MapBuild[name][option.Name] = ""
+		}
 	}
 
 	for attr, val := range tmp {
-		app.MapBuild[tmp["name"].(string)][attr] = val
+		app.MapBuild[name][attr] = val
 	}
+	if len(parent) > 0 {
+		app.MapBuild[name]["parent"] = parent
+	}
+	return nil
 }
 
-// ReviseWidget removes un-templated lines from the build buffer.
+// pythonAttrs returns a copy of a widget's attributes for its template,
+// with the widget type replaced by its Python class, the widget it's
+// placed in as .master and structured values, such as fonts, written as
+// Python literals.
+func pythonAttrs(widget map[string]interface{}, schema WidgetSchema) map[string]interface{} {
+	attrs := make(map[string]interface{}, len(widget))
+	for attr, value := range widget {
+		attrs[attr] = value
+	}
+	if len(schema.Class) > 0 {
+		attrs["widget"] = schema.Class
+	}
+	attrs["master"] = "master"
+	if parent := parentOf(widget); len(parent) > 0 {
+		attrs["master"] = "self." + parent
+	}
+	if spec, hasFont := widget["font"].(string); hasFont {
+		if font, err := ParseFont(spec); err == nil {
+			attrs["font"] = font.Python()
+		}
+	}
+	return attrs
+}
+
+// ReviseWidget removes un-templated lines from a widget's code, applies
+// the code style's comment and line length settings and adds it to the
+// build buffer.
 func (app *AppParser) ReviseWidget(tmpbuff bytes.Buffer) {
 
 	end := []byte(",\n" + app.I2 + ")")
@@ -295,7 +380,11 @@ func (app *AppParser) ReviseWidget(tmpbuff bytes.Buffer) {
 	widgetUpdate := bytes.Split(tmpbuff.Bytes(), []byte("\n"))
 	tmpbuff.Reset()
 
+	style := app.codeStyle()
 	for _, line := range widgetUpdate {
+		if style.Comments != "full" && bytes.HasPrefix(bytes.TrimSpace(line), []byte("#")) {
+			continue
+		}
 		if !bytes.Contains(line, []byte("<no value>")) {
 			tmpbuff.Write(line)
 			tmpbuff.Write([]byte("\n"))
@@ -303,5 +392,6 @@ func (app *AppParser) ReviseWidget(tmpbuff bytes.Buffer) {
 	}
 
 	tmpbuff.Write([]byte("\n"))
-	app.Build.Write(bytes.ReplaceAll(tmpbuff.Bytes(), end, end[1:]))
+	widget := bytes.ReplaceAll(tmpbuff.Bytes(), end, end[1:])
+	app.Build.WriteString(joinCalls(string(widget), style.LineLength))
 }
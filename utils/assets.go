@@ -0,0 +1,33 @@
+package utils
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"embed"
+	"path"
+)
+
+// assets are the designer's window icons, written to the temp directory
+// at startup so that Visipy never needs the network.
+//
+//go:embed assets/icon.ico assets/icon.png assets/icon.gif
+var assets embed.FS
+
+// WriteImgs writes the embedded window icons for life of process only.
+func (btsrp *Bootstrap) WriteImgs(out chan<- struct{}) {
+	entries, err := assets.ReadDir("assets")
+	if err != nil {
+		btsrp.HaveImgs = false
+	}
+	for _, entry := range entries {
+		data, err := assets.ReadFile(path.Join("assets", entry.Name()))
+		if err == nil {
+			err = btsrp.WriteFile(btsrp.TempPath+entry.Name(), data)
+		}
+		if err != nil {
+			btsrp.HaveImgs = false
+		}
+	}
+	out <- struct{}{}
+}
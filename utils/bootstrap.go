@@ -4,24 +4,24 @@ package utils
 // v0.2
 
 import (
-	"bytes"
 	"encoding/json"
-	"io"
 	"io/ioutil"
 	"log"
-	"net/http"
 	"os"
-	"os/exec"
+	"path/filepath"
 )
 
 // Bootstrap decides whether or not to start the GUI.
 type Bootstrap struct {
-	ExePy      string
-	TempPath   string
-	IsPython3  bool
-	HaveImgs   bool
-	HaveGUI    bool
-	HaveConfig bool
+	Python       string
+	ExePy        string
+	TempPath     string
+	IsPython3    bool
+	HaveImgs     bool
+	HaveGUI      bool
+	HaveConfig   bool
+	Capabilities Capabilities
+	pythonErrors []string
 }
 
 // WriteFile writes a plain text file.
@@ -55,28 +55,6 @@ func (btsrp *Bootstrap) CreateProjectFile(basename string, data []byte, out chan
 	out <- struct{}{}
 }
 
-// GetImgs pulls the window icons from the web for life of process only.
-func (btsrp *Bootstrap) GetImgs(url string, out chan<- struct{}) {
-	response, err := http.Get(url)
-	if err != nil {
-		btsrp.HaveImgs = false
-	} else {
-		defer response.Body.Close()
-		file, err := os.Create(btsrp.TempPath + url[len(url)-8:])
-		if err != nil {
-			btsrp.HaveImgs = false
-		}
-		defer file.Close()
-		if btsrp.HaveImgs {
-			_, err = io.Copy(file, response.Body)
-			if err != nil {
-				btsrp.HaveImgs = false
-			}
-		}
-	}
-	out <- struct{}{}
-}
-
 // GetInitialJSON returns the projects default JSON settings.
 func (btsrp Bootstrap) GetInitialJSON() []byte {
 	initJSON := []byte(`{"APPCOLOR": {"appcolor": "black"}, "TITLE": {"title": `)
@@ -86,26 +64,32 @@ func (btsrp Bootstrap) GetInitialJSON() []byte {
 	return append(initJSON, `"THEME": {"theme":"default"}}`...)
 }
 
-// CheckPython ensures Python3 is installed and in path.
+// CheckPython finds a Python 3 interpreter with tkinter among the
+// candidates and probes what it can do.
 func (btsrp *Bootstrap) CheckPython(out chan<- struct{}) {
-	for index, exe := range [4]string{"python", "python", "python3", "python3"} {
-		var output bytes.Buffer
-		pyCheck := exec.Command(exe, "--version")
-		if index%2 != 0 {
-			pyCheck.Stdout = &output
-		} else {
-			pyCheck.Stderr = &output
-		}
-		err := pyCheck.Run()
-		if err == nil && bytes.Contains(output.Bytes(), []byte("Python 3")) {
-			btsrp.IsPython3 = true
-			btsrp.ExePy = exe
-			break
+	for _, exe := range PythonCandidates(btsrp.Python) {
+		caps, err := ProbePython(exe)
+		if err != nil {
+			btsrp.pythonErrors = append(btsrp.pythonErrors, err.Error())
+			continue
 		}
+		btsrp.IsPython3 = true
+		btsrp.ExePy = exe
+		btsrp.Capabilities = caps
+		break
 	}
 	out <- struct{}{}
 }
 
+// WriteCapabilities leaves the interpreter's capabilities in
+// capabilities.json for the designer.
+func (btsrp Bootstrap) WriteCapabilities() {
+	raw, err := json.Marshal(btsrp.Capabilities)
+	if err == nil {
+		btsrp.WriteFile(btsrp.TempPath+"capabilities.json", raw)
+	}
+}
+
 // ErrorExit exits and leaves log in cwd if initial startup errors occur.
 func (btsrp Bootstrap) ErrorExit(warn string) {
 	logfile, _ := os.OpenFile("VISIPY-ERROR-LOG", os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
@@ -114,17 +98,23 @@ func (btsrp Bootstrap) ErrorExit(warn string) {
 	log.Println(warn)
 
 	if !btsrp.IsPython3 {
-		log.Println("Failed to find python3 installation.")
+		log.Println("Failed to find a Python 3 installation with tkinter.")
+		for _, reason := range btsrp.pythonErrors {
+			log.Println(reason)
+		}
 	}
 	if !btsrp.HaveGUI {
 		log.Println("Failed to write Python GUI.")
 	}
 	if !btsrp.HaveImgs {
-		log.Println("Failed to pull application images from the web.")
+		log.Println("Failed to write application images.")
 	}
 	if !btsrp.HaveConfig {
 		log.Println("Failed to write project JSON config.")
 	}
+	if len(btsrp.TempPath) > 0 {
+		os.RemoveAll(btsrp.TempPath)
+	}
 	log.Fatalf("Exiting...")
 }
 
@@ -134,3 +124,18 @@ func (btsrp *Bootstrap) MasterLightOffChecklist() {
 		btsrp.ErrorExit("Unable to start application.")
 	}
 }
+
+// DataDir returns the per-user directory where Visipy keeps data that must
+// outlive a single session, creating it if needed.
+func DataDir() (string, error) {
+	base := os.Getenv("XDG_DATA_HOME")
+	if len(base) < 1 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		base = filepath.Join(home, ".local", "share")
+	}
+	dir := filepath.Join(base, "visipy")
+	return dir, os.MkdirAll(dir, 0700)
+}
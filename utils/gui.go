@@ -14,19 +14,20 @@ func GetGui() []byte {
 import builtins
 from json import load
 from keyword import iskeyword
-from os import rename, environ
+from os import remove, rename, environ
 from os.path import realpath, basename, isfile
-from sys import stdout, modules
+from sys import argv, stdout, modules
 from threading import Thread
 from time import sleep
 from tkinter import Tk, Menu, Label, Spinbox, Entry, LEFT, CENTER
-from tkinter import Button, Listbox, Text, FLAT, SUNKEN
-from tkinter import PhotoImage, Scrollbar, Scale, Toplevel
+from tkinter import Button, Checkbutton, IntVar, Listbox, Text, FLAT, SUNKEN
+from tkinter import PhotoImage, Scrollbar, Scale, Toplevel, BooleanVar
 from tkinter import E, W, END, HORIZONTAL, NORMAL, DISABLED
-from tkinter.messagebox import askyesno
+from tkinter.messagebox import askyesno, showinfo, showwarning
 from tkinter.filedialog import askopenfilename
 from tkinter.filedialog import asksaveasfilename
-from tkinter.font import names
+from tkinter.simpledialog import askstring
+from tkinter.font import families
 from tkinter.ttk import Style
 
 
@@ -40,19 +41,27 @@ class VisiPy:
 		rpath = realpath(__file__)[:-len(basename(__file__))]
 		self.data_path = '%sproject.json' % rpath
 		self.code_path = '%sproject.py' % rpath
+		self.lint_path = '%sproject.lint' % rpath
+		self.error_path = '%sproject.error' % rpath
+		self.capabilities = self.load_capabilities(
+			'%scapabilities.json' % rpath)
+		self.widgets_path = '%sproject.widgets' % rpath
+		self.map_path = '%sproject.map' % rpath
+		self.console_path = '%sproject.console' % rpath
+		self.console, self.console_text, self.console_offset = None, None, 0
+		self.warnings = []
+		self.widget_types = {}
 
 		self.available_widgets = [
 			'Button', 'Checkbutton', 'Entry', 'Image', 'Label',
 			'Listbox', 'Radiobutton', 'Scale', 'Spinbox', 'Text'
 		]
-		self.valid_colors = [
-			'white', 'black', 'red', 'green',
-			'blue', 'cyan', 'yellow', 'magenta'
-		]
 		self.reserved = [
 			'REMOVE', 'THEME', 'WRITE', 'TITLE', 'QUIT',
 			'APPCOLOR', 'GUI', 'DIMENSIONS', 'BUILD'
-			'LOADUSERPROJ', 'MENU', 'MENUCOLOR', 'exit'
+			'LOADUSERPROJ', 'MENU', 'MENUCOLOR', 'UNDO', 'REDO', 'RENAME',
+			'DUPLICATE', 'MOVE', 'INSERTROW', 'DELETEROW', 'INSERTCOLUMN',
+			'DELETECOLUMN', 'STYLE', 'META', 'TARGET', 'exit'
 		]
 		self.reserved += [module for module in dir(modules[__name__])]
 		self.reserved += [name for name in dir(builtins) if name.islower()]
@@ -64,12 +73,11 @@ class VisiPy:
 		self.icon, self.sel, self.popup, self.theme, self.code, self.title = (
 			None for _ in range(6))
 		self.project, self.theme_layout, self.menu_layout, self.menu_color, \
-			self.color, self.xydim, self.font = ({} for _ in range(7))
+			self.color, self.xydim, self.font, self.meta = (
+				{} for _ in range(8))
 		self.is_existing = False
 		self.updated = True
 
-		self.chars = tuple([str(hex(index)[-1:]) for index in range(16)])
-
 		desktop = environ.get('DESKTOP_SESSION')
 		envs = {
 			'plasma': '1085x905',
@@ -114,10 +122,56 @@ class VisiPy:
 		menu.add_cascade(label='File', menu=file_menu)
 
 		edit_menu = Menu(menu)
+		edit_menu.add_command(
+			label='Undo',
+			accelerator='Ctrl+Z',
+			command=self.undo
+		)
+		edit_menu.add_command(
+			label='Redo',
+			accelerator='Ctrl+Y',
+			command=self.redo
+		)
+		edit_menu.add_separator()
+		edit_menu.add_command(
+			label='Rename Widget',
+			command=self.rename_widget
+		)
+		edit_menu.add_command(
+			label='Duplicate Widget',
+			command=self.duplicate_widget
+		)
+		edit_menu.add_command(
+			label='Move Widget',
+			command=self.move_widget
+		)
+		edit_menu.add_separator()
+		edit_menu.add_command(
+			label='Insert Row',
+			command=lambda: self.grid_line('INSERTROW', 'Insert row at:')
+		)
+		edit_menu.add_command(
+			label='Delete Row',
+			command=lambda: self.grid_line('DELETEROW', 'Delete row:')
+		)
+		edit_menu.add_command(
+			label='Insert Column',
+			command=lambda: self.grid_line(
+				'INSERTCOLUMN', 'Insert column at:')
+		)
+		edit_menu.add_command(
+			label='Delete Column',
+			command=lambda: self.grid_line('DELETECOLUMN', 'Delete column:')
+		)
+		edit_menu.add_separator()
 		edit_menu.add_command(
 			label='App Title',
 			command=self.app_title
 		)
+		edit_menu.add_command(
+			label='App Metadata',
+			command=self.app_metadata
+		)
 		edit_menu.add_command(
 			label='App Color',
 			command=self.app_color
@@ -134,7 +188,17 @@ class VisiPy:
 			label='App Theme',
 			command=self.app_theme
 		)
+		edit_menu.add_command(
+			label='Code Style',
+			command=self.code_style
+		)
+		edit_menu.add_command(
+			label='Target Versions',
+			command=self.target_versions
+		)
 		menu.add_cascade(label='Edit', menu=edit_menu)
+		self.master.bind('<Control-z>', lambda _: self.undo())
+		self.master.bind('<Control-y>', lambda _: self.redo())
 
 		extras_menu = Menu(menu)
 		extras_menu.add_command(
@@ -144,6 +208,30 @@ class VisiPy:
 			label='Add Window Menu',
 			command=self.add_menu
 		)
+		extras_menu.add_command(
+			label='Layout Warnings',
+			command=self.show_warnings
+		)
+		extras_menu.add_command(
+			label='Preview Console',
+			command=self.show_console
+		)
+		extras_menu.add_command(
+			label='Python Interpreter',
+			command=self.show_interpreter
+		)
+		self.replace_preview = BooleanVar(value=True)
+		extras_menu.add_checkbutton(
+			label='Replace Running Preview',
+			variable=self.replace_preview,
+			command=self.preview_mode
+		)
+		self.live_preview = BooleanVar(value=False)
+		extras_menu.add_checkbutton(
+			label='Live Preview',
+			variable=self.live_preview,
+			command=self.live_mode
+		)
 		menu.add_cascade(label='Extras', menu=extras_menu)
 
 		self.image_path = PhotoImage(file=rpath + 'icon.gif')
@@ -227,6 +315,7 @@ class VisiPy:
 			pady=5
 		)
 		self.build_box.grid(row=1, rowspan=20, column=2, padx=5)
+		self.build_box.bind('<Double-Button-1>', self.jump_to_source)
 
 		self.widget_selection = ''
 		self.select_new_widget_label = Label(
@@ -541,6 +630,8 @@ class VisiPy:
 		self.populate_code()
 		msg = 'Existing widgets found: %d'
 		self.set_status(msg % self.existing_box.size())
+		if '--recover' in argv:
+			self.master.after(250, self.offer_recovery)
 
 	def set_layout(self):
 		self.layout = {
@@ -1070,6 +1161,23 @@ class VisiPy:
 		})
 		self.disable_remaining()
 
+	def display_custom(self):
+		# Integer options go on the sliders, the rest in the text fields;
+		# unused slots get numeric labels so they are disabled.
+		self.refresh()
+		layout = {'left': {}, 'right': {}}
+		for option in self.widget_types[self.sel]['options']:
+			side = 'left' if option['type'] == 'int' else 'right'
+			if len(layout[side]) < 11:
+				layout[side][option['name']] = -1 if side == 'left' else ''
+		for side in 'left', 'right':
+			pad = 1
+			while len(layout[side]) < 11:
+				layout[side][str(pad)] = -1 if side == 'left' else ''
+				pad += 1
+		self.fill_layout(layout)
+		self.disable_remaining()
+
 	def display_overall_dimensions(self):
 		self.refresh()
 		self.blackout()
@@ -1239,7 +1347,8 @@ class VisiPy:
 				fg='cyan',
 				bg='black',
 				width=18,
-				values=('', 'clam', 'alt', 'classic', 'default'),
+				values=tuple([''] + (self.capabilities['themes'] or [
+					'clam', 'alt', 'classic', 'default'])),
 				highlightbackground=self.dark,
 				command=self.set_theme
 			),
@@ -1798,9 +1907,9 @@ class VisiPy:
 		)
 		self.popup.configure(bg='black')
 
-		directions = '<FontName>  <FontSize>\n\n'
-		directions += 'FontSize is an even integer between 8 & 64.\n'
-		directions += '\nExamples:\nTkTextFont  8\nTkHeadingFont  24'
+		directions = '<Family>  <Size>  [styles]\n\n'
+		directions += 'Styles: bold italic underline overstrike\n'
+		directions += '\nExamples:\nCourier 12\n{DejaVu Sans} 10 bold italic'
 		scrollbar = Scrollbar(self.popup, activebackground=self.light)
 
 		self.font = {
@@ -1872,6 +1981,20 @@ class VisiPy:
 			),
 			'font': ''
 		}
+		for index, style in enumerate(
+				('bold', 'italic', 'underline', 'overstrike')):
+			self.font[style] = IntVar()
+			Checkbutton(
+				self.popup,
+				fg=self.light,
+				bg='black',
+				text=style,
+				font=self.small,
+				selectcolor='black',
+				activebackground='black',
+				highlightthickness=0,
+				variable=self.font[style]
+			).grid(row=4 + index // 2, sticky=[W, E][index % 2], padx=5)
 
 		scrollbar.config(command=self.font['fontbox'].yview)
 
@@ -1895,7 +2018,7 @@ class VisiPy:
 		)
 
 		self.font['fontbox'].delete(0, END)
-		[self.font['fontbox'].insert(END, font) for font in sorted(names())]
+		[self.font['fontbox'].insert(END, f) for f in self.font_families()]
 
 		self.font['fontsize'].grid(
 			row=3,
@@ -1910,19 +2033,44 @@ class VisiPy:
 			sticky=W
 		)
 		self.font['ok'].grid(
-			row=4,
+			row=6,
 			sticky=W,
 			padx=5,
 			pady=5
 		)
 		self.font['cancel'].grid(
-			row=4,
+			row=6,
 			sticky=E,
 			padx=5,
 			pady=5
 		)
 		self.font['fontbox'].select_set(0)
 
+	def font_families(self):
+		return self.capabilities['fonts'] or sorted(set(families()))
+
+	def load_capabilities(self, path):
+		# Probed by the controller from the interpreter previews run with.
+		capabilities = {
+			'python': '', 'tk': '', 'tcl': '', 'themes': None, 'fonts': None,
+			'png': True
+		}
+		try:
+			with open(path) as capabilities_in:
+				capabilities.update(load(capabilities_in))
+		except Exception:
+			pass
+		return capabilities
+
+	def show_interpreter(self):
+		caps = self.capabilities
+		showinfo('Python Interpreter', '\n'.join([
+			'Python %s' % (caps['python'] or 'unknown'),
+			'Tk %s, Tcl %s' % (caps['tk'] or '?', caps['tcl'] or '?'),
+			'Themes: %s' % ', '.join(caps['themes'] or ['unknown']),
+			'Fonts: %d' % len(caps['fonts'] or []),
+			'PNG images: %s' % ('yes' if caps['png'] else 'no')]))
+
 	def message_thread(self, message):
 		th = Thread(target=self.status, args=[message])
 		th.start()
@@ -1997,12 +2145,15 @@ class VisiPy:
 			piped = False
 			stdout.write('%s\n' % action)
 			stdout.flush()
-		elif action in ('RESET', 'BUILD'):
+		elif action in (
+				'RESET', 'BUILD', 'UNDO', 'REDO', 'RESTORE', 'DISCARD'):
 			stdout.write('%s\n' % action)
 			stdout.flush()
 		else:
 			# REMOVE, THEME, WRITE, TITLE, APPCOLOR
 			# ICON, DIMENSIONS, LOADUSERPROJ, MENU, MENUCOLOR
+			# RENAME, DUPLICATE, MOVE, INSERTROW, DELETEROW
+			# INSERTCOLUMN, DELETECOLUMN, STYLE, META, PREVIEW, TARGET
 			stdout.write('%s|$|%s\n' % (action, changes))
 			stdout.flush()
 		if piped:
@@ -2014,16 +2165,14 @@ class VisiPy:
 				exit(1)
 			rename(self.data_path + '.update', self.data_path)
 			rename(self.code_path + '.update', self.code_path)
+			self.populate_add_widgets()
 			self.populate_existing_widgets()
 			self.populate_code()
+			if self.load_error():
+				return
 			self.refresh()
 			self.blackout()
-
-	def valid_color(self, color):
-		color = color.lower()
-		if color in self.valid_colors:
-			return True
-		return all([c in self.chars for c in color]) and len(color) == 6
+			self.load_warnings()
 
 	def review(self):
 		int_vals = (
@@ -2038,19 +2187,7 @@ class VisiPy:
 			'selectbackground', 'selectforeground', 'troughcolor'
 		]
 
-		color_list = self.valid_colors + ['or 6-digit vex value']
-
 		valid = {
-			'activeforeground': color_list,
-			'insertbackground': color_list,
-			'activebackground': color_list,
-			'highlightcolor': color_list,
-			'selectcolor': color_list,
-			'background': color_list,
-			'foreground': color_list,
-			'selectbackground': color_list,
-			'selectforeground': color_list,
-			'troughcolor': color_list,
 			'selectmode': ['BROWSE', 'SINGLE', 'MULTIPLE', 'EXTENDED'],
 			'relief': ['FLAT', 'RAISED', 'GROOVE', 'SUNKEN', 'RIDGE'],
 			'sliderrelief': ['FLAT', 'RAISED', 'GROOVE', 'SUNKEN', 'RIDGE'],
@@ -2059,7 +2196,6 @@ class VisiPy:
 			'indicatoron': ['True', 'False'],
 			'orient': ['HORIZONTAL', 'VERTICAL'],
 			'wrap': ['CHAR', 'WORD'],
-			'font': "see 'Available Fonts' in File menu",
 			'sticky': [
 				'E', 'W', 'N', 'S', 'E+W', 'N+S',
 				'N+W', 'S+W', 'S+E', 'N+E', 'W+E+N+S'
@@ -2115,14 +2251,8 @@ class VisiPy:
 				widget_dict[key] = value
 				continue
 
+			# Fonts are parsed and checked by the controller.
 			if key == 'font':
-				try:
-					font_type, font_size = value.split()
-				except Exception:
-					return {'ERROR': {key: valid[key]}}
-				sizes = [str(i) for i in range(8, 65, 2)]
-				if font_type not in names() or font_size not in sizes:
-					return {'ERROR': {key: valid['font']}}
 				widget_dict[key] = value
 				continue
 
@@ -2143,17 +2273,17 @@ class VisiPy:
 				if not isinstance(value, int) and sep in value:
 					return {'ERROR': {key: 'Chars not allowed: %s' % value}}
 
+			# Colors are parsed and normalized by the controller.
+			if key in color_types:
+				widget_dict[key] = value
+				continue
+
+			# Options of custom widget types are checked by the controller.
+			if key not in valid:
+				widget_dict[key] = value
+				continue
+
 			check = {
-				'activeforeground': self.valid_color(value),
-				'insertbackground': self.valid_color(value),
-				'activebackground': self.valid_color(value),
-				'highlightcolor': self.valid_color(value),
-				'selectcolor': self.valid_color(value),
-				'background': self.valid_color(value),
-				'foreground': self.valid_color(value),
-				'selectbackground': self.valid_color(value),
-				'selectforeground': self.valid_color(value),
-				'troughcolor': self.valid_color(value),
 				'relief': value in valid[key],
 				'sliderrelief': value in valid[key],
 				'selectmode': value in valid[key],
@@ -2180,10 +2310,6 @@ class VisiPy:
 			msg = 'Invalid  Name, name already exists'
 			return {'ERROR': {'name': msg}}
 		widget_dict['name'] = name
-
-		for k, v in widget_dict.items():
-			if k in color_types and v not in self.valid_colors:
-				widget_dict[k] = '#%s' % v
 		return widget_dict
 
 	def add_icon(self):
@@ -2224,6 +2350,125 @@ class VisiPy:
 		else:
 			self.update('REMOVE', name)
 
+	def selected_widget(self):
+		try:
+			return self.existing_box.get(self.existing_box.curselection())
+		except Exception:
+			self.message_thread('Select an existing widget')
+			return None
+
+	def grid_offsets(self, title, prompt, initial, container=False):
+		# With container, a third value names the container to place the
+		# widget in; leaving it out places the widget in the window.
+		value = askstring(title, prompt, initialvalue=initial)
+		if value is None:
+			return None
+		parts = [v.strip() for v in value.split(',')]
+		parent = parts.pop() if container and len(parts) == 3 else ''
+		try:
+			row, column = [int(v) for v in parts]
+		except Exception:
+			self.message_thread('Use two comma-separated integers')
+			return None
+		return (row, column, parent) if container else (row, column)
+
+	def code_style(self):
+		self.load_project_json()
+		style = self.project.get('STYLE', {})
+		current = ', '.join(
+			'%s=%s' % (k, v) for k, v in sorted(style.items()))
+		value = askstring(
+			'Code Style',
+			'indent, quotes, comments, linelength, typehints,\n'
+			'docstrings, imports (e.g. indent=2, quotes=double):',
+			initialvalue=current)
+		if not value:
+			return
+		try:
+			pairs = [p.split('=') for p in value.split(',') if p.strip()]
+			changes = '|:|'.join(
+				'%s|@|%s' % (k.strip(), v.strip()) for k, v in pairs)
+		except ValueError:
+			self.message_thread('Use name=value pairs separated by commas')
+			return
+		self.set_status('Updating code style')
+		self.update('STYLE', changes=changes)
+
+	def target_versions(self):
+		self.load_project_json()
+		target = self.project.get('TARGET', {})
+		current = ', '.join(
+			'%s=%s' % (k, v) for k, v in sorted(target.items()))
+		value = askstring(
+			'Target Versions',
+			'Oldest tk and python to support (e.g. tk=8.5, python=3.5),\n'
+			'empty values clear them:',
+			initialvalue=current)
+		if value is None:
+			return
+		try:
+			pairs = [p.split('=') for p in value.split(',') if p.strip()]
+			changes = '|:|'.join(
+				'%s|@|%s' % (k.strip(), v.strip()) for k, v in pairs)
+		except ValueError:
+			self.message_thread('Use name=value pairs separated by commas')
+			return
+		if not changes:
+			changes = 'tk|@||:|python|@|'
+		self.set_status('Updating target versions')
+		self.update('TARGET', changes=changes)
+
+	def rename_widget(self):
+		name = self.selected_widget()
+		if not name:
+			return
+		new_name = askstring('Rename Widget', 'New name for %s:' % name)
+		if not new_name:
+			return
+		new_name = new_name.strip()
+		if new_name in self.reserved or new_name in self.project:
+			self.message_thread('Invalid  Name %s is taken' % new_name)
+		elif not new_name.isidentifier() or iskeyword(new_name):
+			self.message_thread('Invalid  Name, use Python naming conventions')
+		else:
+			self.update('RENAME', changes='%s|:|%s' % (name, new_name))
+
+	def duplicate_widget(self):
+		name = self.selected_widget()
+		if not name:
+			return
+		offsets = self.grid_offsets(
+			'Duplicate Widget', 'Row, column offset for the copy:', '1, 0')
+		if offsets:
+			self.update('DUPLICATE', changes='%s|:|%d|:|%d' % (
+				(name,) + offsets))
+
+	def move_widget(self):
+		name = self.selected_widget()
+		if not name:
+			return
+		cell = self.project[name]
+		initial = '%s, %s' % (cell.get('row', 0), cell.get('column', 0))
+		if cell.get('parent'):
+			initial += ', %s' % cell['parent']
+		offsets = self.grid_offsets(
+			'Move Widget', 'New row, column[, container] for %s:' % name,
+			initial, container=True)
+		if offsets:
+			self.update('MOVE', changes='%s|:|%d|:|%d|:|%s' % (
+				(name,) + offsets))
+
+	def grid_line(self, action, prompt):
+		# An index in the window's grid, or index, container.
+		value = askstring('Grid', '%s\n(index[, container])' % prompt)
+		if value is None:
+			return
+		parts = [v.strip() for v in value.split(',')]
+		if len(parts) > 2 or not parts[0].isdigit():
+			self.message_thread('Use an index and optionally a container')
+			return
+		self.update(action, changes='|:|'.join(parts))
+
 	def add_style(self):
 		if not self.theme:
 			self.theme_layout['warnlabel'].configure(text='Select a theme')
@@ -2236,14 +2481,83 @@ class VisiPy:
 		title = self.title['title'].get('1.0', END).strip()
 		if not title:
 			self.title['warnlabel'].configure(text='Enter a title')
-		elif not title.isidentifier() or iskeyword(title):
-			msg = 'Use valid Python\nnaming conventions'
-			self.title['warnlabel'].configure(text=msg)
+		elif any(sep in title for sep in ('|:|', '|$|', '|@|')):
+			self.title['warnlabel'].configure(text='Chars not allowed: |')
 		else:
 			self.popup.destroy()
 			self.update('TITLE', changes=title)
 			self.title = None
 
+	def app_metadata(self):
+		self.refresh()
+		self.blackout()
+		self.load_project_json()
+		meta = self.project.get('META', {})
+		self.popup = Toplevel()
+		self.popup.title('Application Metadata')
+		self.popup.geometry(
+			"+%d+%d" % (
+				self.master.winfo_x() + 100,
+				self.master.winfo_y() + 100
+			)
+		)
+		self.popup.configure(bg='black')
+
+		# Blank fields are cleared; class and module names default to
+		# the title.
+		self.meta = {}
+		fields = ('classname', 'module', 'version', 'author', 'license')
+		for row, field in enumerate(fields):
+			Label(
+				self.popup,
+				fg='white',
+				text='%s:' % field,
+				anchor=W,
+				bg='black',
+				width=18,
+				height=1,
+				font=self.normal
+			).grid(row=row, column=0, sticky=W, padx=5, pady=5)
+			self.meta[field] = Entry(
+				self.popup,
+				fg='cyan',
+				bg='black',
+				width=24,
+				insertbackground='#33CC00',
+				font=self.normal
+			)
+			self.meta[field].insert(0, meta.get(field, ''))
+			self.meta[field].grid(row=row, column=1, sticky=E, padx=5, pady=5)
+		Button(
+			self.popup,
+			fg='green',
+			bg='black',
+			text='Ok',
+			font=self.small,
+			width=5,
+			command=self.add_metadata
+		).grid(row=len(fields), column=0, sticky=W, padx=5, pady=5)
+		Button(
+			self.popup,
+			fg='red',
+			bg='black',
+			text='Cancel',
+			font=self.small,
+			width=5,
+			command=self.popup.destroy
+		).grid(row=len(fields), column=0, sticky=E, padx=5, pady=5)
+
+	def add_metadata(self):
+		values = [(k, e.get().strip()) for k, e in self.meta.items()]
+		for _, value in values:
+			if any(sep in value for sep in ('|:|', '|$|', '|@|')):
+				self.message_thread('Chars not allowed: %s' % value)
+				return
+		self.popup.destroy()
+		self.update('META', changes='|:|'.join(
+			'%s|@|%s' % (k, v) for k, v in values))
+		self.meta = {}
+
 	def add_menu_item(self):
 		menu = self.menu_layout['menu_title'].get('1.0', END).strip()
 		subs = self.menu_layout['submenus'].get('1.0', END).strip()
@@ -2262,28 +2576,21 @@ class VisiPy:
 	def add_color(self):
 		color = self.color['color'].get('1.0', END).strip()
 
-		if self.valid_color(color):
-			if color not in self.valid_colors:
-				color = '#%s' % color
+		if color:
 			self.popup.destroy()
 			self.update('APPCOLOR', changes=color)
 			self.color = {}
 		else:
-			self.color['warnlabel'].configure(text='Invalid app color')
-			self.color['color'].delete('1.0', END)
+			self.color['warnlabel'].configure(text='Enter an app color')
 
 	def add_menu_color(self):
 		fg = self.menu_color['fg'].get('1.0', END).strip()
 		bg = self.menu_color['bg'].get('1.0', END).strip()
-		if not self.valid_color(fg):
-			self.menu_color['warnlabel'].configure(text='Invalid foreground')
-		elif not self.valid_color(bg):
-			self.menu_color['warnlabel'].configure(text='Invalid background')
+		if not fg:
+			self.menu_color['warnlabel'].configure(text='Missing foreground')
+		elif not bg:
+			self.menu_color['warnlabel'].configure(text='Missing background')
 		else:
-			if fg not in self.valid_colors:
-				fg = '#%s' % fg
-			if bg not in self.valid_colors:
-				bg = '#%s' % bg
 			self.popup.destroy()
 			self.update('MENUCOLOR', changes='%s|:|%s' % (fg, bg))
 			self.menu_color = {}
@@ -2296,16 +2603,87 @@ class VisiPy:
 		font_size = self.font['font']
 		if not font_size:
 			font_size = '8'
+		spec = '{%s} %s' % (font, font_size)
+		for style in 'bold', 'italic', 'underline', 'overstrike':
+			if self.font[style].get():
+				spec += ' %s' % style
 		for k, v in self.layout.items():
 			if 'label' in k and self.layout[k].cget('text') == 'font':
 				text_box = k.split('_')[0]
 				self.layout[text_box].delete('1.0', END)
-				self.layout[text_box].insert(END, '%s %s' % (font, font_size))
+				self.layout[text_box].insert(END, spec)
 		self.popup.destroy()
 
 	def build(self):
 		self.message_thread('Executing current build...')
 		self.update('BUILD')
+		self.show_console()
+		self.console_offset = 0
+		self.console_text.delete('1.0', END)
+
+	def preview_mode(self):
+		if self.replace_preview.get():
+			self.update('PREVIEW', 'replace')
+		else:
+			self.update('PREVIEW', 'keep')
+
+	def live_mode(self):
+		if self.live_preview.get():
+			self.message_thread('The preview restarts as the project changes')
+			self.update('PREVIEW', 'live')
+		else:
+			self.update('PREVIEW', 'manual')
+
+	def show_console(self):
+		if self.console and self.console.winfo_exists():
+			self.console.lift()
+			return
+		self.console = Toplevel()
+		self.console.title('Preview Console')
+		self.console.configure(bg='black')
+		scrollbar = Scrollbar(self.console, activebackground=self.light)
+		self.console_text = Text(
+			self.console,
+			fg='#79ff4d',
+			bg='black',
+			width=100,
+			height=20,
+			font=self.small,
+			yscrollcommand=scrollbar.set
+		)
+		scrollbar.config(command=self.console_text.yview)
+		self.console_text.grid(row=0, column=0, padx=5, pady=5)
+		scrollbar.grid(row=0, column=1, sticky='ns')
+		self.console_offset = 0
+		self.poll_console()
+
+	def poll_console(self):
+		# The controller writes the preview's output to the console file.
+		if not self.console or not self.console.winfo_exists():
+			return
+		try:
+			with open(self.console_path) as console_in:
+				# A restarted preview starts the console file over.
+				console_in.seek(0, 2)
+				if console_in.tell() < self.console_offset:
+					self.console_offset = 0
+					self.console_text.delete('1.0', END)
+				console_in.seek(self.console_offset)
+				output = console_in.read()
+				self.console_offset = console_in.tell()
+		except (OSError, ValueError):
+			output = ''
+		if output:
+			self.console_text.insert(END, output)
+			self.console_text.see(END)
+			for line in output.split('\n'):
+				if line.startswith(('visipy: preview exited with',
+						'visipy: preview crashed')):
+					self.message_thread(line[len('visipy: '):])
+				_, found, source = line.partition('visipy: the error is at')
+				if found and ', in ' in source:
+					self.select_source(source.split(', in ', 1)[1], False)
+		self.console.after(500, self.poll_console)
 
 	def fill_current_widget(self):
 		if not self.sel:
@@ -2340,6 +2718,9 @@ class VisiPy:
 		elif self.sel == 'Text':
 			self.set_name_txt('Text')
 			self.display_text()
+		elif self.sel in self.widget_types:
+			self.set_name_txt(self.sel)
+			self.display_custom()
 
 	def set_current_widget(self):
 		self.is_existing = False
@@ -2366,10 +2747,6 @@ class VisiPy:
 					tmp = self.project[name]['values'].replace('\'', '')
 					self.project[name]['values'] = tmp
 
-				for attr, value in self.project[name].items():
-					if isinstance(value, str) and value[:1] == '#':
-						self.project[name][attr] = value[1:]
-
 				self.fill_current_widget()
 				self.fill_existing_widget(name)
 			except Exception:
@@ -2441,17 +2818,43 @@ class VisiPy:
 			self.update('EXIT')
 			Tk().quit()
 
+	def offer_recovery(self):
+		msg = 'Visipy did not exit cleanly.\nRestore the unsaved session?'
+		if askyesno('Restore Session', msg):
+			self.set_status('Restoring session')
+			self.update('RESTORE')
+		else:
+			self.update('DISCARD')
+
 	def reset(self):
 		if askyesno('Reset Project', 'Clear entire project?'):
 			self.set_status('Resetting')
 			self.update('RESET')
 
+	def undo(self):
+		self.set_status('Undo')
+		self.update('UNDO')
+
+	def redo(self):
+		self.set_status('Redo')
+		self.update('REDO')
+
 	def set_theme(self):
 		self.theme = self.theme_layout['theme'].get()
 
 	def populate_add_widgets(self):
+		self.load_widget_types()
+		self.new_box.delete(0, END)
 		[self.new_box.insert(END, w) for w in self.available_widgets]
 
+	def load_widget_types(self):
+		# Written by the controller, including custom widget types.
+		if not isfile(self.widgets_path):
+			return
+		with open(self.widgets_path) as widgets_in:
+			self.widget_types = {w['name']: w for w in load(widgets_in)}
+		self.available_widgets = sorted(self.widget_types)
+
 	def populate_existing_widgets(self):
 		self.load_project_json()
 		widgets = [key for key in self.project if key not in self.reserved]
@@ -2467,8 +2870,11 @@ class VisiPy:
 		self.build_box.insert('end', *self.code.split('\n'))
 
 	def write_file(self):
+		self.load_project_json()
+		module = self.project.get('META', {}).get('module', '')
 		file_path = asksaveasfilename(
 			title='Write Current Build',
+			initialfile='%s.py' % module if module else '',
 			filetypes=(('all files', '*.*'),)
 		)
 		if file_path:
@@ -2486,6 +2892,71 @@ class VisiPy:
 		with open(self.data_path) as file_in:
 			self.project = load(file_in)
 
+	def jump_to_source(self, event):
+		# The controller maps each line of the build to its source.
+		line = self.build_box.nearest(event.y) + 1
+		try:
+			with open(self.map_path) as map_in:
+				sources = load(map_in) or []
+		except Exception:
+			return
+		source = ''
+		for lines in sources:
+			if lines['start'] <= line <= lines['end']:
+				source = lines['source']
+				break
+		if source:
+			self.select_source(source, True)
+
+	def select_source(self, source, open_settings):
+		kind, _, name = source.partition(' ')
+		settings = {
+			'META': self.app_metadata,
+			'TITLE': self.app_title,
+			'APPCOLOR': self.app_color,
+			'DIMENSIONS': self.display_overall_dimensions,
+			'MENUCOLOR': self.window_menu_color,
+			'ICON': self.add_icon,
+			'THEME': self.app_theme
+		}
+		if kind in ('widget', 'menu'):
+			names = self.existing_box.get(0, END)
+			if name in names:
+				index = names.index(name)
+				self.existing_box.selection_clear(0, END)
+				self.existing_box.selection_set(index)
+				self.existing_box.see(index)
+				self.set_existing_widget()
+		elif kind == 'setting' and name in settings and open_settings:
+			settings[name]()
+		else:
+			self.message_thread('Generated %s code' % name)
+
+	def load_error(self):
+		if not isfile(self.error_path):
+			return False
+		with open(self.error_path) as error_in:
+			error = error_in.read().strip()
+		remove(self.error_path)
+		attr = error.split(':')[0]
+		self.warn_thread(error, attr)
+		return True
+
+	def load_warnings(self):
+		if not isfile(self.lint_path):
+			return
+		with open(self.lint_path) as lint_in:
+			self.warnings = [w for w in lint_in.read().split('\n') if w]
+		if self.warnings:
+			self.message_thread('Layout warnings: %d (see Extras)' % len(
+				self.warnings))
+
+	def show_warnings(self):
+		if not self.warnings:
+			showinfo('Layout Warnings', 'No layout problems found')
+		else:
+			showwarning('Layout Warnings', '\n'.join(self.warnings))
+
 	def load_project_code(self):
 		with open(self.code_path) as code_in:
 			self.code = code_in.read()
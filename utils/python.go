@@ -0,0 +1,120 @@
+package utils
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Capabilities are what the Python interpreter running the designer and
+// the previews can do, as reported by capabilityProbe. Versions are empty
+// and lists nil when they could not be probed, e.g. without a display.
+type Capabilities struct {
+	Python string   `json:"python"`
+	Tk     string   `json:"tk"`
+	Tcl    string   `json:"tcl"`
+	Themes []string `json:"themes"`
+	Fonts  []string `json:"fonts"`
+	PNG    bool     `json:"png"`
+	Error  string   `json:"error,omitempty"`
+}
+
+// capabilityProbe prints the interpreter's Capabilities as JSON. It only
+// uses syntax Python 2 understands, so that a wrong interpreter can be
+// told apart from a broken one.
+const capabilityProbe = `
+import json, sys
+caps = {'python': '%d.%d.%d' % tuple(sys.version_info[:3]), 'tk': '',
+        'tcl': '', 'themes': None, 'fonts': None, 'png': False}
+try:
+    import tkinter
+    from tkinter import font, ttk
+except ImportError as err:
+    caps['error'] = str(err)
+else:
+    caps['tk'], caps['tcl'] = str(tkinter.TkVersion), str(tkinter.TclVersion)
+    caps['png'] = tkinter.TkVersion >= 8.6
+    try:
+        root = tkinter.Tk()
+        root.withdraw()
+        caps['tk'] = str(root.tk.call('set', 'tk_patchLevel'))
+        caps['tcl'] = str(root.tk.call('info', 'patchlevel'))
+        caps['themes'] = sorted(ttk.Style(root).theme_names())
+        caps['fonts'] = sorted(set(font.families(root)))
+        caps['png'] = 'png' in root.tk.call('image', 'formats') or caps['png']
+        root.destroy()
+    except tkinter.TclError:
+        pass
+print(json.dumps(caps))
+`
+
+// configFile holds the user's settings, in the visipy config directory.
+const configFile = "config.json"
+
+// Config are the user's settings, e.g. {"python": "/usr/bin/python3.8"}.
+type Config struct {
+	Python string `json:"python"`
+}
+
+// LoadConfig reads the user's settings. A missing file is not an error.
+func LoadConfig() (Config, error) {
+	var config Config
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return config, err
+	}
+	raw, err := ioutil.ReadFile(filepath.Join(dir, "visipy", configFile))
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err == nil {
+		err = json.Unmarshal(raw, &config)
+	}
+	return config, err
+}
+
+// PythonCandidates lists the interpreters to try in order. One that was
+// asked for, with --python or in the config file, is the only candidate;
+// otherwise an active virtualenv comes before python3 and python on PATH.
+func PythonCandidates(requested string) []string {
+	if len(requested) > 0 {
+		return []string{requested}
+	}
+	var candidates []string
+	if venv := os.Getenv("VIRTUAL_ENV"); len(venv) > 0 {
+		if runtime.GOOS == "windows" {
+			candidates = append(candidates, filepath.Join(venv, "Scripts", "python.exe"))
+		} else {
+			candidates = append(candidates, filepath.Join(venv, "bin", "python"))
+		}
+	}
+	return append(candidates, "python3", "python")
+}
+
+// ProbePython runs capabilityProbe with exe and fails unless it is Python
+// 3 with tkinter.
+func ProbePython(exe string) (Capabilities, error) {
+	var caps Capabilities
+	output, err := exec.Command(exe, "-c", capabilityProbe).Output()
+	if err != nil {
+		return caps, fmt.Errorf("%s: %v", exe, err)
+	}
+	if err := json.Unmarshal(output, &caps); err != nil {
+		return caps, fmt.Errorf("%s: unexpected probe output: %v", exe, err)
+	}
+	if !strings.HasPrefix(caps.Python, "3.") {
+		return caps, fmt.Errorf("%s: Python %s is not Python 3", exe, caps.Python)
+	}
+	if len(caps.Error) > 0 {
+		return caps, fmt.Errorf("%s: tkinter is not available: %s", exe, caps.Error)
+	}
+	return caps, nil
+}
@@ -0,0 +1,97 @@
+package utils
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	tempPrefix = "visipy-"
+	pidFile    = "visipy.pid"
+)
+
+// unlockedAge is how old a temp directory without a PID file has to be
+// before it is taken for abandoned rather than still being set up.
+const unlockedAge = time.Hour
+
+// MakeTempDir creates this instance's temp directory under $TMPDIR and
+// claims it with a PID file. It returns the path with a trailing separator.
+func MakeTempDir() (string, error) {
+	dir, err := os.MkdirTemp("", tempPrefix)
+	if err != nil {
+		return "", err
+	}
+	pid := []byte(strconv.Itoa(os.Getpid()))
+	if err := ioutil.WriteFile(filepath.Join(dir, pidFile), pid, 0600); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir + string(os.PathSeparator), nil
+}
+
+// CleanTempDirs removes the temp directories left behind by instances that
+// exited early. Directories of instances that are still running are kept.
+func CleanTempDirs() {
+	dirs, _ := filepath.Glob(filepath.Join(os.TempDir(), tempPrefix+"*"))
+	for _, dir := range dirs {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() && abandoned(dir, info) {
+			os.RemoveAll(dir)
+		}
+	}
+}
+
+// abandoned reports whether the process that created dir has exited.
+func abandoned(dir string, info os.FileInfo) bool {
+	raw, err := ioutil.ReadFile(filepath.Join(dir, pidFile))
+	if err != nil {
+		return errors.Is(err, os.ErrNotExist) && time.Since(info.ModTime()) > unlockedAge
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(raw)))
+	if err != nil {
+		return false
+	}
+	return pid != os.Getpid() && !ProcessAlive(pid)
+}
+
+// ProcessAlive reports whether pid is a running process, which may belong
+// to another user.
+func ProcessAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// RemoveOnSignal removes dir and exits if SIGINT or SIGTERM arrive before
+// the returned function is called.
+func RemoveOnSignal(dir string) func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	stopped := make(chan struct{})
+	go func() {
+		select {
+		case received := <-signals:
+			os.RemoveAll(dir)
+			fmt.Fprintf(os.Stderr, "visipy: %v\n", received)
+			os.Exit(1)
+		case <-stopped:
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(stopped)
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+// BSD 3-Clause License Copyright (c) 2020
+// v0.2
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rootVIII/visipy/control"
+)
+
+const usage = `usage:
+  visipy [--python interpreter]        start the designer
+  visipy batch [-o output.py] [script] apply a command script (stdin if omitted)
+  visipy lint project                  check a .project file's grid layout
+  visipy render [-o out.png] project   draw a .project file's layout as SVG or PNG
+  visipy templates [dir]               write the default code templates for editing
+`
+
+// runCommand runs a command line sub-command and returns the exit code.
+func runCommand(args []string) int {
+	loadUserConfig()
+	switch args[0] {
+	case "batch":
+		return runBatch(args[1:])
+	case "lint":
+		return runLint(args[1:])
+	case "render":
+		return runRender(args[1:])
+	case "templates":
+		return runTemplates(args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+		return 0
+	}
+	fmt.Fprintf(os.Stderr, "visipy: unknown command %q\n%s", args[0], usage)
+	return 2
+}
+
+// runBatch applies a designer script and writes the resulting build either
+// to the -o path (with its .project file) or to stdout.
+func runBatch(args []string) int {
+	flags := flag.NewFlagSet("batch", flag.ContinueOnError)
+	output := flags.String("o", "", "write the build and its .project file here")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	var script io.Reader = os.Stdin
+	name := "stdin"
+	if flags.NArg() > 0 && flags.Arg(0) != "-" {
+		file, err := os.Open(flags.Arg(0))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "visipy: %v\n", err)
+			return 1
+		}
+		defer file.Close()
+		script = file
+		name = flags.Arg(0)
+	}
+
+	// Custom widgets and templates may live next to the script, as they
+	// do next to a project file.
+	dir := "."
+	if name != "stdin" {
+		dir = filepath.Dir(name)
+	}
+	err := control.LoadWidgetDir(filepath.Join(dir, control.WidgetDirName))
+	if err == nil {
+		err = control.LoadTemplateDir(filepath.Join(dir, control.TemplateDirName))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "visipy: %v\n", err)
+		return 1
+	}
+
+	app := &control.AppParser{}
+	if err := app.RunBatch(script); err != nil {
+		fmt.Fprintf(os.Stderr, "visipy: %s: %v\n", name, err)
+		return 1
+	}
+
+	if len(*output) > 0 {
+		if err := app.ApplyCommand([]string{"WRITE", *output}); err != nil {
+			fmt.Fprintf(os.Stderr, "visipy: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	if err := app.WriteBuild(os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "visipy: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runLint prints the layout warnings of each .project file given and
+// fails if any were found.
+func runLint(args []string) int {
+	if len(args) < 1 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+	status := 0
+	for _, path := range args {
+		app := &control.AppParser{}
+		if err := app.LoadProject(path); err != nil {
+			fmt.Fprintf(os.Stderr, "visipy: %v\n", err)
+			status = 1
+			continue
+		}
+		for _, warning := range app.Lint() {
+			fmt.Printf("%s: %s\n", path, warning)
+			status = 1
+		}
+	}
+	return status
+}
+
+// runRender draws a .project file's layout to the -o path, as PNG when it
+// ends in .png and as SVG otherwise, or as SVG to stdout.
+func runRender(args []string) int {
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	output := flags.String("o", "", "write the image here (.svg or .png)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	app := &control.AppParser{}
+	if err := app.LoadProject(flags.Arg(0)); err != nil {
+		fmt.Fprintf(os.Stderr, "visipy: %v\n", err)
+		return 1
+	}
+	render := control.RenderSVG
+	if strings.EqualFold(filepath.Ext(*output), ".png") {
+		render = control.RenderPNG
+	}
+
+	var out io.Writer = os.Stdout
+	if len(*output) > 0 {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "visipy: %v\n", err)
+			return 1
+		}
+		defer file.Close()
+		out = file
+	}
+	if err := render(app.MapBuild, out); err != nil {
+		fmt.Fprintf(os.Stderr, "visipy: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// loadUserConfig registers the custom widget types and template overrides
+// in the user's config directory; broken files are reported but don't stop
+// Visipy.
+func loadUserConfig() {
+	dir, err := control.UserWidgetDir()
+	if err == nil {
+		err = control.LoadWidgetDir(dir)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "visipy: custom widgets: %v\n", err)
+	}
+	dir, err = control.UserTemplateDir()
+	if err == nil {
+		err = control.LoadTemplateDir(dir)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "visipy: templates: %v\n", err)
+	}
+}
+
+// runTemplates writes the built-in templates to a directory, the user's
+// template directory by default, so they can be edited as overrides.
+func runTemplates(args []string) int {
+	dir, err := control.UserTemplateDir()
+	if len(args) > 0 {
+		dir, err = args[0], nil
+	}
+	if err == nil {
+		err = control.WriteDefaultTemplates(dir)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "visipy: %v\n", err)
+		return 1
+	}
+	fmt.Printf("templates written to %s\n", dir)
+	return 0
+}
@@ -30,67 +30,81 @@ THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
 package main
 
 import (
+	"flag"
 	"fmt"
-	"io/ioutil"
 	"os"
-	"strings"
-	"time"
 
 	"github.com/rootVIII/visipy/control"
 	"github.com/rootVIII/visipy/utils"
 )
 
 func main() {
+	flags := flag.NewFlagSet("visipy", flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	python := flags.String("python", "", "the Python 3 interpreter to use")
+	flags.Parse(os.Args[1:])
+	if flags.NArg() > 0 {
+		os.Exit(runCommand(flags.Args()))
+	}
+
+	// --python wins over the config file, which wins over an active
+	// virtualenv and the interpreters on PATH.
+	if len(*python) < 1 {
+		config, err := utils.LoadConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "visipy: config: %v\n", err)
+		}
+		*python = config.Python
+	}
+
 	var bootstrap = &utils.Bootstrap{
+		Python:     *python,
 		IsPython3:  false,
 		HaveImgs:   true,
 		HaveGUI:    true,
 		HaveConfig: true,
 	}
-	tmp := fmt.Sprintf("visipy-%s", time.Now().Format("20060102150405"))
-	bootstrap.TempPath = fmt.Sprintf("/var/tmp/%s/", tmp)
-	tmpdir := bootstrap.TempPath[:len(bootstrap.TempPath)-(len(tmp)+1)]
-
-	// Clean up any leftover/old project files in case the
-	// application exited early during previous usage.
-	contents, err := ioutil.ReadDir(tmpdir)
+	// Clean up the project files of instances that exited early, then
+	// claim a directory of our own; other running instances keep theirs.
+	utils.CleanTempDirs()
+	tempPath, err := utils.MakeTempDir()
 	if err != nil {
-		bootstrap.ErrorExit(fmt.Sprintf("Unable to read:\n%s", tmpdir))
-	}
-	for _, file := range contents {
-		if !strings.Contains(file.Name(), "visipy-") {
-			continue
-		}
-		os.RemoveAll(fmt.Sprintf("%s%s", tmpdir, file.Name()))
-	}
-	os.Mkdir(bootstrap.TempPath, 0700)
-
-	urls := [3]string{
-		"https://raw.githubusercontent.com/visipy/vpy/master/icon.ico",
-		"https://raw.githubusercontent.com/visipy/vpy/master/icon.png",
-		"https://raw.githubusercontent.com/visipy/vpy/master/icon.gif",
+		bootstrap.ErrorExit(fmt.Sprintf("Unable to create a temp directory:\n%v", err))
 	}
+	bootstrap.TempPath = tempPath
+	stopSignals := utils.RemoveOnSignal(bootstrap.TempPath)
 
 	ch := make(chan struct{})
-	for _, url := range urls {
-		go bootstrap.GetImgs(url, ch)
-	}
+	go bootstrap.WriteImgs(ch)
 	go bootstrap.CheckPython(ch)
 	go bootstrap.CreateProjectFile("project.json", bootstrap.GetInitialJSON(), ch)
 	go bootstrap.CreateProjectFile("gui.py", utils.GetGui(), ch)
-	for i := 0; i < 6; i++ {
+	for i := 0; i < 4; i++ {
 		<-ch
 	}
 
 	bootstrap.MasterLightOffChecklist()
+	bootstrap.WriteCapabilities()
+	loadUserConfig()
+
+	// Autosave and crash recovery are disabled without a data directory.
+	dataDir, err := utils.DataDir()
+	if err != nil {
+		dataDir = ""
+	}
 
 	var visipy control.Controller
 	visipy = &control.AppParser{
-		Executable: bootstrap.ExePy,
-		VisiPath:   bootstrap.TempPath + "gui.py",
-		Project:    bootstrap.TempPath + "project",
+		Executable:   bootstrap.ExePy,
+		VisiPath:     bootstrap.TempPath + "gui.py",
+		Project:      bootstrap.TempPath + "project",
+		DataDir:      dataDir,
+		Capabilities: bootstrap.Capabilities,
 	}
 
+	// From here on the controller handles SIGINT and SIGTERM by closing
+	// the designer, so that the cleanup below still runs.
+	stopSignals()
 	visipy.RunVisipy()
 	_ = os.RemoveAll(bootstrap.TempPath)
 }